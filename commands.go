@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/farizkhoo/cuti-cli/scraper"
+)
+
+// command is a cuti subcommand. run gets the arguments after its name.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+// commands lists the subcommands, in the order usage shows them. Without
+// one, cuti runs scrape, so flags-only invocations keep working. It's a
+// function because usage, which runScrape uses, refers back to it.
+func commands() []command {
+	return []command{
+		{"scrape", "fetch holidays and write them out (the default)", runScrape},
+		{"serve", "serve holidays over HTTP; scrape -serve", runServe},
+		{"export", "convert saved JSON output to another format without scraping", runExport},
+		{"diff", "compare two saved JSON outputs", runDiff},
+	}
+}
+
+func main() {
+	name, args := "scrape", os.Args[1:]
+	if len(args) > 0 {
+		if args[0] == "help" {
+			usage(flag.CommandLine, "", "")
+			return
+		}
+		if i := slices.IndexFunc(commands(), func(c command) bool { return c.name == args[0] }); i >= 0 {
+			name, args = args[0], args[1:]
+		} else if !strings.HasPrefix(args[0], "-") {
+			// a typo'd command would otherwise stop flag parsing and
+			// run a default scrape with every later flag dropped
+			fmt.Fprintf(flag.CommandLine.Output(), "cuti: unknown command %q\n\n", args[0])
+			usage(flag.CommandLine, "", "")
+			os.Exit(2)
+		}
+	}
+	for _, c := range commands() {
+		if c.name == name {
+			c.run(args)
+			return
+		}
+	}
+}
+
+// usage prints the subcommands and, for a subcommand, its flags
+func usage(fs *flag.FlagSet, name, operands string) {
+	out := fs.Output()
+	if name != "" {
+		fmt.Fprintf(out, "Usage: cuti %s [flags] %s\n\nFlags:\n", name, operands)
+		fs.PrintDefaults()
+		fmt.Fprintln(out)
+	} else {
+		fmt.Fprintf(out, "Usage: cuti [command] [flags]\n\n")
+	}
+	fmt.Fprintf(out, "Commands:\n")
+	for _, c := range commands() {
+		fmt.Fprintf(out, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(out, "\nRun cuti <command> -h for a command's flags.\n")
+}
+
+// runServe is scrape with -serve set, taking all of scrape's flags
+func runServe(args []string) {
+	runScrape(append([]string{"-serve"}, args...))
+}
+
+// runExport consolidates the JSON files given as arguments, as -merge
+// does, and writes them in another format
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "csv", "Output format: "+strings.Join(formats(), ", "))
+	out := fs.String("out", "holidays", "Output file name without extension, or - for stdout")
+	outDir := fs.String("out-dir", ".", "Directory the output file is written to")
+	gzipOut := fs.Bool("gzip", false, "Gzip the output and add .gz to the file name")
+	envelope := fs.Bool("envelope", false, "Wrap JSON output in an object with schemaVersion and generatedAt")
+	fileMode := fs.String("file-mode", "0644", "Permissions for the output file, in octal")
+	logFormat := fs.String("log-format", "text", "Log format: text or json")
+	fs.Usage = func() { usage(fs, "export", "file.json...") }
+	fs.Parse(args)
+
+	if err := setupLogging(*logFormat, os.Stderr, slog.LevelInfo, os.Getenv("NO_COLOR") == "" && isTerminal(os.Stderr)); err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() == 0 {
+		log.Fatalf("export needs JSON files to read after the flags")
+	}
+	ext, err := normalizeFormat(*format)
+	if err != nil {
+		log.Fatalf("Unsupported format: %v", err)
+	}
+	if *envelope && ext != "json" {
+		log.Fatalf("-envelope only works with -format json")
+	}
+	if ext == "sqlite" && (*out == "-" || *gzipOut) {
+		log.Fatalf("The sqlite format needs a plain file; it can't be written to stdout or gzipped")
+	}
+	mode, err := strconv.ParseUint(*fileMode, 8, 32)
+	if err != nil || mode > 0777 {
+		log.Fatalf("Invalid -file-mode value %q (expected octal permissions like 0644)", *fileMode)
+	}
+	opts := outputOptions{envelope: *envelope, fileMode: os.FileMode(mode), gzip: *gzipOut}
+
+	all, years, err := loadMerge(fs.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	final := scraper.Consolidate(all)
+
+	if *out == "-" {
+		if err := writeCompressed(os.Stdout, ext, final, opts); err != nil {
+			log.Fatal(err)
+		}
+		slog.Info("✅ Holidays written to stdout", "file", "stdout", "holidays", len(final))
+		return
+	}
+	saveFormat := ext
+	if *gzipOut {
+		ext += ".gz"
+	}
+	dest := filepath.Join(*outDir, fmt.Sprintf("%s-%s.%s", *out, yearsLabel(years), ext))
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		log.Fatal(err)
+	}
+	if err := saveOutput(dest, saveFormat, final, opts); err != nil {
+		log.Fatal(err)
+	}
+	slog.Info(fmt.Sprintf("✅ Holidays written to %s", dest), "file", dest, "holidays", len(final))
+}
+
+// runDiff logs what changed between two JSON outputs, oldest first
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	exitCode := fs.Bool("exit-code", false, "Exit with status 1 if the files differ")
+	logFormat := fs.String("log-format", "text", "Log format: text or json")
+	fs.Usage = func() { usage(fs, "diff", "old.json new.json") }
+	fs.Parse(args)
+
+	if err := setupLogging(*logFormat, os.Stderr, slog.LevelInfo, os.Getenv("NO_COLOR") == "" && isTerminal(os.Stderr)); err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() != 2 {
+		log.Fatalf("diff needs two JSON files: the old one and the new one")
+	}
+	previous, err := scraper.LoadJSON(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("loading %s: %v", fs.Arg(0), err)
+	}
+	current, err := scraper.LoadJSON(fs.Arg(1))
+	if err != nil {
+		log.Fatalf("loading %s: %v", fs.Arg(1), err)
+	}
+
+	d := scraper.DiffHolidays(scraper.Consolidate(previous), scraper.Consolidate(current))
+	printDiff(fs.Arg(0), d)
+	if *exitCode && !d.Empty() {
+		os.Exit(1)
+	}
+}
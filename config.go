@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyConfig sets flags from the YAML or JSON file at path, keyed by flag
+// name without the dash, e.g. {format: csv, states: [SGR, KUL]}. Flags
+// given on the command line win, so it must run after flag.Parse. Lists are
+// joined with commas to match flags like -states.
+func applyConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// JSON is valid YAML, so one decoder covers both
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	for name, v := range values {
+		if name == "config" {
+			return fmt.Errorf("%s: config files can't include other configs", path)
+		}
+		if flag.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown flag %q", path, name)
+		}
+		if explicit[name] {
+			continue
+		}
+		if err := flag.Set(name, configValue(v)); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// configValue formats a decoded config value the way it would be typed on
+// the command line
+func configValue(v any) string {
+	if list, ok := v.([]any); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v)
+}
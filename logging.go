@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// setupLogging routes both slog and the log package through a handler for
+// format, writing to w and dropping anything below level. "text" keeps the
+// familiar emoji lines, colored by level when color is set; "json" emits one
+// structured object per entry for log aggregators.
+func setupLogging(format string, w io.Writer, level slog.Level, color bool) error {
+	var h slog.Handler
+	switch format {
+	case "text":
+		h = &textHandler{w: w, mu: &sync.Mutex{}, level: level, color: color}
+	case "json":
+		h = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	default:
+		return fmt.Errorf("unsupported log format: %s (expected text or json)", format)
+	}
+	slog.SetDefault(slog.New(h))
+
+	// Only log.Fatal calls remain on the log package, so tag them as errors
+	slog.SetLogLoggerLevel(slog.LevelError)
+	return nil
+}
+
+// textHandler prints just the message, formatted the way the log package
+// would, and drops attributes: the messages already carry them for humans.
+type textHandler struct {
+	w     io.Writer
+	mu    *sync.Mutex
+	level slog.Level
+	color bool
+}
+
+// ANSI colors for textHandler messages
+const (
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+// colorize wraps msg in the color for its level: red for errors, yellow
+// for warnings and green for the "✅" success lines. Other info lines are
+// left plain.
+func colorize(level slog.Level, msg string) string {
+	var c string
+	switch {
+	case level >= slog.LevelError:
+		c = ansiRed
+	case level >= slog.LevelWarn:
+		c = ansiYellow
+	case strings.HasPrefix(msg, "✅"):
+		c = ansiGreen
+	default:
+		return msg
+	}
+	return c + msg + ansiReset
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msg := r.Message
+	if h.color {
+		msg = colorize(r.Level, msg)
+	}
+	_, err := fmt.Fprintf(h.w, "%s %s\n", r.Time.Format("2006/01/02 15:04:05"), msg)
+	return err
+}
+
+func (h *textHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *textHandler) WithGroup(string) slog.Handler      { return h }
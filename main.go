@@ -1,62 +1,1106 @@
 package main
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/farizkhoo/cuti-cli/scraper"
 )
 
-func main() {
-	year := flag.Int("year", 2025, "Year to fetch holidays for")
-	format := flag.String("format", "json", "Output format: json or csv")
-	out := flag.String("out", "holidays", "Output file name without extension")
+// formats lists every value accepted by -format: the registered writers,
+// plus sqlite, which is upserted rather than streamed, and markdown, an
+// alias for md
+func formats() []string {
+	return append(scraper.Formats(), "markdown", "sqlite")
+}
+
+// runScrape is the scrape subcommand, and what cuti does without one:
+// fetch holidays and write them out. args are the flags after the
+// subcommand, parsed into the global flag set.
+func runScrape(args []string) {
+	year := yearFlag(2025)
+	flag.Var(&year, "year", `Year to fetch holidays for, or "current" (or 0) for the latest year the site publishes`)
+	yearsFlag := flag.String("years", "", "Years to fetch, e.g. 2023-2025 or 2024,2026 (overrides -year)")
+	fromFlag := flag.String("from", "", "Start of a date range (YYYY-MM-DD) to fetch and keep; needs -to")
+	toFlag := flag.String("to", "", "End of the -from date range (YYYY-MM-DD), inclusive")
+	format := flag.String("format", "json", "Output format: "+strings.Join(formats(), ", "))
+	out := flag.String("out", "holidays", "Output file name without extension, or - for stdout")
+	logFormat := flag.String("log-format", "text", "Log format: text or json")
+	noColor := flag.Bool("no-color", false, "Don't color text log lines (also off when NO_COLOR is set or stderr isn't a terminal)")
 	headless := flag.Bool("headless", false, "Run Chrome in headless mode")
-	flag.Parse()
+	timeout := flag.Duration("timeout", 20*time.Second, "Per-page load timeout, e.g. 45s")
+	userAgent := flag.String("user-agent", scraper.DefaultUserAgent, "User-Agent sent with every page load (empty keeps Chrome's)")
+	baseURL := flag.String("base-url", scraper.DefaultBaseURL, "Site to scrape, e.g. a local mirror serving the same paths")
+	proxy := flag.String("proxy", "", "Proxy for all browser traffic, e.g. http://host:3128 or socks5://host:1080")
+	retries := flag.Int("retries", 3, "Retry a failed page load this many times")
+	retryBackoff := flag.Duration("retry-backoff", 2*time.Second, "Wait before the first retry (doubles each attempt)")
+	concurrency := flag.Int("concurrency", 4, "Number of states to fetch in parallel")
+	delay := flag.Duration("delay", time.Second, "Minimum time between page requests to the site, shared across -concurrency tabs (0 disables)")
+	maxFailures := flag.Int("max-failures", 0, "Abort once this many state fetches have failed in total (0 = never)")
+	failOnPartial := flag.Bool("fail-on-partial", false, "Exit nonzero if any state fails to fetch (with -serve, answer 502 instead of partial data)")
+	dumpRaw := flag.String("dump-raw", "", "Directory to write each page's raw table cells to as <state>-<year>.json, for bug reports")
+	cacheDir := flag.String("cache-dir", "", "Directory to cache fetched pages and parsed holidays in")
+	refresh := flag.Bool("refresh", false, "Ignore the cache and fetch every page again")
+	maxAge := flag.Duration("max-age", 0, "Treat cache entries older than this as missing, e.g. 24h (0 never expires)")
+	longWeekends := flag.Bool("long-weekends", false, "Print long weekends and bridge days after scraping")
+	compareStates := flag.String("compare-states", "", "Comma-separated states (two or more) to scrape and print the holidays that differ between them")
+	byMonth := flag.Bool("by-month", false, "Print the holidays month by month after scraping")
+	strict := flag.Bool("strict", false, "Fail a state if any of its rows is invalid instead of skipping the row, and fail on same-date name conflicts")
+	diffFile := flag.String("diff", "", "JSON file from an earlier run to compare the fresh data against")
+	month := flag.Int("month", 0, "Only output holidays in this month (1-12)")
+	serveMode := flag.Bool("serve", false, "Serve holidays over HTTP instead of writing a file")
+	tuiMode := flag.Bool("tui", false, "Browse the holidays in an interactive table instead of writing a file")
+	port := flag.Int("port", 8080, "Port for -serve")
+	metricsPort := flag.Int("metrics-port", 0, "Serve Prometheus metrics on this port at /metrics while running (0 disables)")
+	dryRun := flag.Bool("dry-run", false, "Print the URLs that would be fetched and exit without launching Chrome")
+	progress := flag.Bool("progress", false, "Show a progress bar instead of per-state log lines (terminals only)")
+	splitByState := flag.Bool("split-by-state", false, "Also write one <out>-<state>.<format> file per state into -out-dir")
+	noCombined := flag.Bool("no-combined", false, "With -split-by-state, skip the consolidated file")
+	outDir := flag.String("out-dir", ".", "Directory output files are written to")
+	appendMode := flag.Bool("append", false, "Merge into an existing <out>.json instead of overwriting a per-year file")
+	includeObservances := flag.Bool("include-observances", false, "Keep rows the site marks as observances rather than official days off")
+	gazettedOnly := flag.Bool("gazetted-only", false, "Deprecated: observances are now dropped unless -include-observances is set")
+	quiet := flag.Bool("quiet", false, "Only log warnings and errors")
+	onlyNames := flag.String("only-names", "", "Comma-separated holiday names (or aliases) to keep, e.g. \"Hari Raya Aidilfitri,Deepavali\"")
+	hideWeekend := flag.Bool("hide-weekend-holidays", false, "Drop holidays that fall on a Saturday or Sunday")
+	nationalOnly := flag.Bool("national-only", false, "Keep only holidays observed in every state (see -national-min) or listed on the national page")
+	nationalMin := flag.Int("national-min", 0, "With -national-only, how many states a holiday must cover (0 means all 16; 9 is a majority)")
+	noBlock := flag.Bool("no-block-resources", false, "Let pages load images, fonts and CSS (slower, for when the table needs them)")
+	chromePath := flag.String("chrome-path", "", "Chrome or Chromium binary to run (default: search PATH and the usual install locations)")
+	tableSelector := flag.String("table-selector", scraper.DefaultTableSelector, "CSS selector of the holiday table to wait for")
+	yearHeader := flag.String("year-header-regex", "", "Regex h1-h3 headings must match to mark the year's table, with {year} for the year (default "+scraper.DefaultYearHeader+")")
+	verify := flag.Bool("verify", false, "Warn when a state's holiday count is outside -verify-min..-verify-max")
+	verifyMin := flag.Int("verify-min", 12, "Fewest holidays -verify expects per state per year")
+	verifyMax := flag.Int("verify-max", 22, "Most holidays -verify expects per state per year")
+	withHijri := flag.Bool("with-hijri", false, "Add each holiday's date in the tabular Islamic calendar as hijriDate (may be a day off the sighted date)")
+	recomputeDays := flag.Bool("recompute-days", false, "Replace the scraped Day with the weekday computed from the date")
+	gcalCalendar := flag.String("gcal-calendar-id", "", "Also upsert the holidays into this Google Calendar (needs -credentials)")
+	credentials := flag.String("credentials", "", "Google service account or authorized user JSON for -gcal-calendar-id")
+	gcalPrune := flag.Bool("gcal-prune", false, "With -gcal-calendar-id, delete events this tool added earlier that are no longer holidays")
+	upcoming := flag.Bool("upcoming", false, "Only keep holidays from today onwards")
+	limit := flag.Int("limit", 0, "Keep at most this many holidays, earliest first (0 = all)")
+	mergeMode := flag.Bool("merge", false, "Combine the JSON files given as arguments into one consolidated output instead of scraping")
+	check := flag.Bool("check", false, "Check that the first selected state's page still has the expected structure, report OK/FAIL and exit")
+	summary := flag.Bool("summary", false, "Log a summary of the run at the end: holidays per state, weekend holidays, failed states")
+	dateFormat := flag.String("date-format", "", "Output date layout: iso, dmy (02/01/2006), mdy (01/02/2006), long (2 Jan 2006) or a Go layout")
+	fileMode := flag.String("file-mode", "0644", "Permissions for output files, in octal")
+	gzipOut := flag.Bool("gzip", false, "Gzip the output and add .gz to file names")
+	envelope := flag.Bool("envelope", false, "Wrap JSON output in an object with schemaVersion and generatedAt")
+	statesFlag := flag.String("states", "", "Comma-separated state slugs or codes (e.g. SGR,KUL) to fetch (default: all)")
+	excludeStates := flag.String("exclude-states", "", "Comma-separated state slugs or codes to leave out of the states fetched")
+	sample := flag.Int("sample", 0, "Only fetch the first N of the selected states, for quick test runs (0 fetches them all)")
+	lang := flag.String("lang", scraper.LangEnglish, "Language of holiday names: en or ms (Bahasa Malaysia)")
+	namesFile := flag.String("names", "", "YAML file of extra holiday name aliases (canonical name: [aliases])")
+	configFile := flag.String("config", "", "YAML or JSON file of default flag values; command-line flags override it")
+	flag.CommandLine.Usage = func() { usage(flag.CommandLine, "scrape", "") }
+	flag.CommandLine.Parse(args)
+
+	if *configFile != "" {
+		if err := applyConfig(*configFile); err != nil {
+			log.Fatalf("Invalid -config: %v", err)
+		}
+	}
+
+	baseLevel := slog.LevelInfo
+	if *quiet {
+		baseLevel = slog.LevelWarn
+	}
+
+	// The bar only makes sense for a human watching a terminal; anywhere
+	// else keep the line-based logs
+	var bar *progressBar
+	logOut, logLevel := io.Writer(os.Stderr), baseLevel
+	if *progress && !*quiet && *logFormat == "text" && isTerminal(os.Stderr) {
+		bar = newProgressBar(os.Stderr)
+		logOut, logLevel = bar, slog.LevelWarn
+	}
+	// logs go to stderr, so that's the stream that has to be a terminal
+	color := !*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stderr)
+	if err := setupLogging(*logFormat, logOut, logLevel, color); err != nil {
+		log.Fatal(err)
+	}
+
+	// Validate before launching Chrome so a typo doesn't cost a full scrape
+	normalizedFormat, err := normalizeFormat(*format)
+	if err != nil {
+		log.Fatalf("Unsupported format: %v", err)
+	}
+
+	if *appendMode && (normalizedFormat != "json" || *out == "-") {
+		log.Fatalf("-append only works with -format json and a file -out")
+	}
+
+	if *tuiMode && (*serveMode || !isTerminal(os.Stdout)) {
+		log.Fatalf("-tui needs a terminal on stdout and can't be combined with -serve")
+	}
+
+	if (*gcalCalendar == "") != (*credentials == "") {
+		log.Fatalf("-gcal-calendar-id and -credentials must be given together")
+	}
+
+	mode, err := strconv.ParseUint(*fileMode, 8, 32)
+	if err != nil || mode > 0777 {
+		log.Fatalf("Invalid -file-mode value %q (expected octal permissions like 0644)", *fileMode)
+	}
+	if *yearHeader != "" {
+		if err := scraper.CheckYearHeader(*yearHeader); err != nil {
+			log.Fatalf("Invalid -year-header-regex %q: %v", *yearHeader, err)
+		}
+	}
+	outOpts := outputOptions{envelope: *envelope, fileMode: os.FileMode(mode), gzip: *gzipOut}
+	if *dateFormat != "" {
+		layout, err := parseDateFormat(*dateFormat)
+		if err != nil {
+			log.Fatalf("Invalid -date-format value %q: %v", *dateFormat, err)
+		}
+		// these need dates they can parse back, or the format fixes them
+		if *appendMode || normalizedFormat == "ics" || normalizedFormat == "sqlite" {
+			log.Fatalf("-date-format can't be used with -append or the ics and sqlite formats")
+		}
+		if layout != scraper.DateLayout {
+			outOpts.dateLayout = layout
+		}
+	}
+
+	if *envelope && normalizedFormat != "json" {
+		log.Fatalf("-envelope only works with -format json")
+	}
+
+	if *splitByState && (*out == "-" || *appendMode) {
+		log.Fatalf("-split-by-state writes files, so it can't be combined with -out - or -append")
+	}
+	if *noCombined && !*splitByState {
+		log.Fatalf("-no-combined only makes sense with -split-by-state")
+	}
+
+	if normalizedFormat == "sqlite" && *out == "-" {
+		log.Fatalf("The sqlite format needs a file; it can't be written to stdout")
+	}
+	if *gzipOut && (normalizedFormat == "sqlite" || *appendMode) {
+		log.Fatalf("-gzip can't be combined with -format sqlite or -append, which update files in place")
+	}
+
+	if *limit < 0 {
+		log.Fatalf("Invalid -limit value %d (expected 0 or more)", *limit)
+	}
+
+	if *mergeMode && (flag.NArg() == 0 || *serveMode || *check || *dryRun) {
+		log.Fatalf("-merge needs JSON files after the flags and can't be combined with -serve, -check or -dry-run")
+	}
+	if !*mergeMode && flag.NArg() > 0 {
+		log.Fatalf("Unexpected argument %q; flags go before any arguments, and only -merge takes files", flag.Arg(0))
+	}
+
+	if *month < 0 || *month > 12 {
+		log.Fatalf("Invalid -month value %d (expected 1-12)", *month)
+	}
+
+	if *lang != scraper.LangEnglish && *lang != scraper.LangMalay {
+		log.Fatalf("Invalid -lang value %q (expected en or ms)", *lang)
+	}
+
+	if *namesFile != "" {
+		if err := scraper.LoadNameAliases(*namesFile); err != nil {
+			log.Fatalf("Invalid -names file: %v", err)
+		}
+	}
+
+	if u, err := url.Parse(*baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		log.Fatalf("Invalid -base-url value %q (expected e.g. http://localhost:8000)", *baseURL)
+	}
+
+	if *proxy != "" {
+		if err := checkProxy(*proxy); err != nil {
+			log.Fatalf("Invalid -proxy value %q: %v", *proxy, err)
+		}
+	}
+
+	years := []int{int(year)}
+	if *yearsFlag != "" {
+		var err error
+		years, err = parseYears(*yearsFlag)
+		if err != nil {
+			log.Fatalf("Invalid -years value %q: %v", *yearsFlag, err)
+		}
+	}
 
-	normalizedFormat := strings.ToLower(*format)
-	if normalizedFormat != "json" && normalizedFormat != "csv" {
-		log.Fatalf("Unsupported format: %s (expected json or csv)", *format)
+	// a date range picks its own years, spanning a boundary if it needs to
+	var from, to time.Time
+	if *fromFlag != "" || *toFlag != "" {
+		if *fromFlag == "" || *toFlag == "" || *yearsFlag != "" {
+			log.Fatalf("-from and -to must be given together, and not with -years")
+		}
+		var err error
+		if from, err = time.Parse(scraper.DateLayout, *fromFlag); err != nil {
+			log.Fatalf("Invalid -from value %q (expected YYYY-MM-DD)", *fromFlag)
+		}
+		if to, err = time.Parse(scraper.DateLayout, *toFlag); err != nil {
+			log.Fatalf("Invalid -to value %q (expected YYYY-MM-DD)", *toFlag)
+		}
+		if to.Before(from) {
+			log.Fatalf("-to %s is before -from %s", *toFlag, *fromFlag)
+		}
+		years = nil
+		for y := from.Year(); y <= to.Year(); y++ {
+			years = append(years, y)
+		}
 	}
 
 	// States only (national excluded)
-	states := []string{
-		"johor", "kedah", "kelantan", "kuala-lumpur",
-		"labuan", "melaka", "negeri-sembilan", "pahang",
-		"penang", "perak", "perlis", "putrajaya",
-		"sabah", "sarawak", "selangor", "terengganu",
+	states := scraper.AllStates()
+	if *statesFlag != "" {
+		// national is never fetched by default but can be asked for
+		var err error
+		states, err = parseStates(*statesFlag, scraper.ValidStates())
+		if err != nil {
+			log.Fatalf("Invalid -states value %q: %v", *statesFlag, err)
+		}
+	}
+	if *excludeStates != "" {
+		excluded, err := parseStates(*excludeStates, scraper.ValidStates())
+		if err != nil {
+			log.Fatalf("Invalid -exclude-states value %q: %v", *excludeStates, err)
+		}
+		states = slices.DeleteFunc(states, func(st string) bool { return slices.Contains(excluded, st) })
+		if len(states) == 0 {
+			log.Fatalf("-exclude-states leaves no states to fetch")
+		}
+	}
+
+	if *sample < 0 {
+		log.Fatalf("Invalid -sample value %d (expected 0 or more)", *sample)
+	}
+	if *sample > 0 && *sample < len(states) {
+		slog.Warn(fmt.Sprintf("🧪 -sample: fetching only %d of %d states (%s); output is incomplete", *sample, len(states), strings.Join(states[:*sample], ", ")),
+			"sample", *sample, "states", states[:*sample])
+		states = states[:*sample]
+	}
+
+	// comparing picks the states to scrape itself
+	if *compareStates != "" {
+		if *statesFlag != "" || *excludeStates != "" || *sample > 0 {
+			log.Fatalf("-compare-states chooses the states to fetch; drop -states, -exclude-states and -sample")
+		}
+		var err error
+		states, err = parseStates(*compareStates, scraper.AllStates())
+		if err != nil {
+			log.Fatalf("Invalid -compare-states value %q: %v", *compareStates, err)
+		}
+		if len(states) < 2 {
+			log.Fatalf("-compare-states needs at least two states")
+		}
+	}
+
+	// a holiday can't cover more states than were fetched
+	if *nationalOnly && !slices.Contains(states, scraper.National) {
+		need := *nationalMin
+		if need <= 0 {
+			need = len(scraper.AllStates())
+		}
+		if len(states) < need {
+			log.Fatalf("-national-only needs holidays in %d states but only %d are fetched; widen -states or lower -national-min", need, len(states))
+		}
+	}
+
+	// -year current is looked up on the site once the browser is up
+	autoYear := len(years) == 1 && years[0] == 0
+	if autoYear && *dryRun {
+		log.Fatalf("-year current has to load a page to find the year, so it can't be combined with -dry-run")
 	}
 
-	s := scraper.NewScraper(*headless)
-	defer s.Close()
+	if *dryRun {
+		for _, y := range years {
+			for _, st := range states {
+				pageURL := scraper.PageURL(*baseURL, st, y)
+				slog.Info(fmt.Sprintf("🔗 %s (%d): %s", st, y, pageURL), "state", st, "year", y, "url", pageURL)
+			}
+		}
+		return
+	}
 
+	// Load before scraping so a bad path fails fast
+	var previous []scraper.Holiday
+	if *diffFile != "" {
+		var err error
+		previous, err = scraper.LoadJSON(*diffFile)
+		if err != nil {
+			log.Fatalf("Could not load -diff file: %v", err)
+		}
+	}
+
+	// Ctrl-C stops scraping but still writes what was collected. A second
+	// one, once scraping has stopped, kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	opts := []scraper.Option{
+		scraper.WithContext(ctx),
+		scraper.WithHeadless(*headless),
+		scraper.WithBaseURL(*baseURL),
+		scraper.WithTimeout(*timeout),
+		scraper.WithUserAgent(*userAgent),
+		scraper.WithRetries(*retries, *retryBackoff),
+		scraper.WithConcurrency(*concurrency),
+		scraper.WithDelay(*delay),
+		scraper.WithCacheDir(*cacheDir),
+		scraper.WithRefresh(*refresh),
+		scraper.WithMaxAge(*maxAge),
+		scraper.WithProxy(*proxy),
+		scraper.WithStrict(*strict),
+		scraper.WithTableSelector(*tableSelector),
+		scraper.WithChromePath(*chromePath),
+		scraper.WithYearHeaderRegex(*yearHeader),
+		scraper.WithBlockResources(!*noBlock),
+	}
+	if bar != nil {
+		opts = append(opts, scraper.WithProgress(bar.Update))
+	}
+	if *dumpRaw != "" {
+		if err := os.MkdirAll(*dumpRaw, 0755); err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, scraper.WithRawRows(func(state string, year int, rows [][]string) {
+			path := filepath.Join(*dumpRaw, fmt.Sprintf("%s-%d.json", state, year))
+			if err := saveRawRows(path, rows); err != nil {
+				slog.Warn(fmt.Sprintf("⚠️  Could not dump raw rows for %s (%d): %v", state, year, err),
+					"state", state, "year", year, "error", err)
+			}
+		}))
+	}
+	if *metricsPort > 0 {
+		m := newFetchMetrics()
+		opts = append(opts, scraper.WithFetchStats(m.observe))
+		serveMetrics(fmt.Sprintf(":%d", *metricsPort), m)
+	}
+	// the count never resets, so a long-running server would stay aborted
+	if !*serveMode {
+		opts = append(opts, scraper.WithMaxFailures(*maxFailures))
+	}
+	var s *scraper.Scraper
 	var all []scraper.Holiday
-	for i, st := range states {
-		log.Printf("🌐 [%d/%d] Fetching %s (%d)…", i+1, len(states), st, *year)
+	failed := 0
+	var failures []string
+	if *mergeMode {
+		var err error
+		all, years, err = loadMerge(flag.Args())
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		var err error
+		s, err = scraper.StartScraper(opts...)
+		if err != nil {
+			if errors.Is(err, scraper.ErrNoBrowser) {
+				log.Fatalf("⛔ %v\n   Install Google Chrome or Chromium (see the README), or point -chrome-path at the binary", err)
+			}
+			log.Fatal(err)
+		}
+		defer s.Close()
+
+		if autoYear {
+			y, err := s.LatestYear(ctx, states[0])
+			if err != nil {
+				log.Fatalf("⛔ Could not find the latest published year on the %s page: %v", states[0], err)
+			}
+			slog.Info(fmt.Sprintf("📅 Latest year published for %s is %d; using it", states[0], y), "state", states[0], "year", y)
+			years = []int{y}
+		}
+
+		if *check {
+			st, y := states[0], years[0]
+			res, err := s.CheckPage(ctx, st, y)
+			s.Close()
+			if err != nil {
+				log.Fatalf("⛔ FAIL: could not load %s (%d): %v", st, y, err)
+			}
+			if !res.OK() {
+				log.Fatalf("⛔ FAIL: %s: %s", res.URL, strings.Join(res.Problems(), "; "))
+			}
+			slog.Info(fmt.Sprintf("✅ OK: %s has the year header and a table with %d rows", res.URL, res.Rows),
+				"url", res.URL, "rows", res.Rows, "shortRows", res.ShortRows)
+			return
+		}
+
+		if *serveMode {
+			hs := newHolidayServer(s, states, years, *maxAge, *includeObservances && !*gazettedOnly, *failOnPartial)
+			if err := serve(fmt.Sprintf(":%d", *port), hs); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		for _, y := range years {
+			// per-state failures are already logged by FetchAll
+			holidays, err := s.FetchAll(ctx, y, states)
+			all = append(all, holidays...)
+			if ctx.Err() != nil {
+				break
+			}
+			failures = append(failures, failedStates(err)...)
+			failed = len(failures)
+			if errors.Is(err, scraper.ErrTooManyFailures) {
+				log.Fatalf("⛔ Giving up after %d failed fetches (-max-failures); is the site down?", failed)
+			}
+		}
+	}
+	interrupted := ctx.Err() != nil
 
-		holidays, err := s.FetchState(st, *year)
+	final := scraper.Consolidate(all)
+	if bar != nil {
+		// back to normal logging for the summary lines
+		bar.Finish()
+		_ = setupLogging(*logFormat, os.Stderr, baseLevel, color)
+	}
+
+	total := len(years) * len(states)
+	if len(final) == 0 {
+		log.Fatalf("⛔ No holidays collected (%d/%d fetches failed); not writing output", failed, total)
+	}
+	if interrupted {
+		slog.Warn(fmt.Sprintf("🛑 Interrupted; writing the %d holidays collected so far as a partial result", len(final)),
+			"holidays", len(final))
+	} else if failed > 0 {
+		if *failOnPartial {
+			log.Fatalf("⛔ %d/%d fetches failed; not writing output (-fail-on-partial)", failed, total)
+		}
+		slog.Warn(fmt.Sprintf("⚠️  %d/%d fetches failed; output is incomplete", failed, total),
+			"failed", failed, "total", total)
+	}
+
+	if conflicts := scraper.FindDateConflicts(final); len(conflicts) > 0 {
+		for _, c := range conflicts {
+			slog.Warn(fmt.Sprintf("⚠️  %s has %d holidays in %s: %s", c.Date, len(c.Names), strings.Join(c.States, ", "), strings.Join(c.Names, " / ")),
+				"date", c.Date, "names", c.Names, "states", c.States)
+		}
+		if *strict {
+			log.Fatalf("⛔ %d dates list more than one holiday for the same state; not writing output (-strict)", len(conflicts))
+		}
+	}
+
+	// before -verify, so it counts the holidays that are written
+	dropObservances := *gazettedOnly || !*includeObservances
+	if dropObservances {
+		final = scraper.FilterGazetted(final)
+	}
+
+	if *verify {
+		implausible := 0
+		for _, y := range years {
+			counts := scraper.CountByState(final, y)
+			verified := states
+			if *mergeMode {
+				// merged files only cover the states they were scraped for
+				verified = slices.Sorted(maps.Keys(scraper.CountByState(all, y)))
+			}
+			for _, st := range verified {
+				if n := counts[st]; n < *verifyMin || n > *verifyMax {
+					implausible++
+					slog.Warn(fmt.Sprintf("⚠️  %s (%d) has %d holidays, outside the expected %d-%d", st, y, n, *verifyMin, *verifyMax),
+						"state", st, "year", y, "holidays", n, "min", *verifyMin, "max", *verifyMax)
+				}
+			}
+		}
+		if implausible > 0 && *strict {
+			log.Fatalf("⛔ %d state counts failed -verify; not writing output (-strict)", implausible)
+		}
+	}
+
+	f := filters{
+		from:          from,
+		to:            to,
+		month:         time.Month(*month),
+		nationalOnly:  *nationalOnly,
+		nationalMin:   *nationalMin,
+		names:         splitList(*onlyNames),
+		hideWeekend:   *hideWeekend,
+		recomputeDays: *recomputeDays,
+		withHijri:     *withHijri,
+		upcoming:      *upcoming,
+		now:           time.Now(),
+		limit:         *limit,
+		lang:          *lang,
+	}
+	for _, n := range f.names {
+		if len(scraper.FilterByNames(final, []string{n})) == 0 {
+			slog.Warn(fmt.Sprintf("⚠️  -only-names: no holiday is called %q", n), "name", n)
+		}
+	}
+	final = f.apply(final)
+
+	if *longWeekends {
+		printLongWeekends(final)
+	}
+	if *byMonth {
+		printByMonth(final)
+	}
+	if *compareStates != "" {
+		printStateComparison(final, states)
+	}
+	if *diffFile != "" {
+		printDiff(*diffFile, diffPrevious(previous, final, dropObservances, f))
+	}
+
+	if *tuiMode {
+		if err := browse(final); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	partial := ""
+	if interrupted {
+		partial = ".partial"
+	}
+	ext := normalizedFormat
+	if *gzipOut {
+		ext += ".gz"
+	}
+	if *splitByState {
+		if err := os.MkdirAll(*outDir, 0755); err != nil {
+			log.Fatal(err)
+		}
+		for _, st := range states {
+			path := filepath.Join(*outDir, fmt.Sprintf("%s-%s%s.%s", *out, st, partial, ext))
+			stateHolidays := scraper.FilterByState(final, st)
+			if err := saveOutput(path, normalizedFormat, stateHolidays, outOpts); err != nil {
+				log.Fatal(err)
+			}
+			slog.Info(fmt.Sprintf("✅ %s holidays written to %s", st, path), "state", st, "file", path, "holidays", len(stateHolidays))
+		}
+	}
+
+	// "-" streams to stdout; logs stay on stderr so they don't mix in
+	dest := "stdout"
+	switch {
+	case *splitByState && *noCombined:
+		// the per-state files are the whole output
+	case *out == "-":
+		if err := writeCompressed(os.Stdout, normalizedFormat, final, outOpts); err != nil {
+			log.Fatal(err)
+		}
+		slog.Info(fmt.Sprintf("✅ Holidays written to %s", dest), "file", dest, "holidays", len(final))
+	default:
+		dest = filepath.Join(*outDir, fmt.Sprintf("%s-%s%s.%s", *out, yearsLabel(years), partial, ext))
+
+		// The appended file accumulates years, so it isn't named after them
+		if *appendMode {
+			dest = filepath.Join(*outDir, *out+".json")
+			existing, err := scraper.LoadJSON(dest)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Fatalf("Could not load %s to append to: %v", dest, err)
+			}
+			slog.Info(fmt.Sprintf("➕ Merging into %d existing holidays from %s", len(existing), dest),
+				"file", dest, "existing", len(existing))
+			final = scraper.Consolidate(append(existing, final...))
+		}
+
+		if err := os.MkdirAll(*outDir, 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := saveOutput(dest, normalizedFormat, final, outOpts); err != nil {
+			log.Fatal(err)
+		}
+		slog.Info(fmt.Sprintf("✅ Holidays written to %s", dest), "file", dest, "holidays", len(final))
+	}
+
+	if *gcalCalendar != "" {
+		res, err := scraper.PushGoogleCalendar(context.Background(), *credentials, *gcalCalendar, final, *gcalPrune && !interrupted)
+		slog.Info(fmt.Sprintf("📅 Google Calendar: %d created, %d updated, %d deleted", res.Created, res.Updated, res.Deleted),
+			"calendar", *gcalCalendar, "created", res.Created, "updated", res.Updated, "deleted", res.Deleted)
 		if err != nil {
-			log.Printf("⛔ Failed to fetch %s (%d): %v", st, *year, err)
+			log.Fatalf("⛔ Pushing to Google Calendar: %v", err)
+		}
+	}
+
+	if *summary {
+		printSummary(final, failures)
+	}
+
+	if interrupted {
+		if s != nil {
+			s.Close()
+		}
+		os.Exit(130)
+	}
+}
+
+// filters are the flags that narrow down and rename the consolidated
+// holidays before they're written
+type filters struct {
+	// from and to bound the dates kept, when from isn't zero
+	from, to time.Time
+	// month keeps one month, when it isn't zero
+	month        time.Month
+	nationalOnly bool
+	nationalMin  int
+	// names keeps only these holidays, by name or alias
+	names         []string
+	hideWeekend   bool
+	recomputeDays bool
+	withHijri     bool
+	// upcoming drops holidays before now
+	upcoming bool
+	now      time.Time
+	limit    int
+	lang     string
+}
+
+// apply runs holidays through f, narrowing them down first and
+// localizing names last
+func (f filters) apply(holidays []scraper.Holiday) []scraper.Holiday {
+	if !f.from.IsZero() {
+		holidays = scraper.FilterByDateRange(holidays, f.from, f.to)
+	}
+	if f.month != 0 {
+		holidays = scraper.FilterByMonth(holidays, f.month)
+	}
+	if f.nationalOnly {
+		holidays = scraper.FilterNational(holidays, f.nationalMin)
+	}
+	if len(f.names) > 0 {
+		holidays = scraper.FilterByNames(holidays, f.names)
+	}
+	if f.hideWeekend {
+		holidays = scraper.FilterWeekdays(holidays)
+	}
+	if f.recomputeDays {
+		holidays = scraper.RecomputeDays(holidays)
+	}
+	if f.withHijri {
+		holidays = scraper.AddHijriDates(holidays)
+	}
+	if f.upcoming {
+		holidays = scraper.FilterUpcoming(holidays, f.now)
+	}
+	if f.limit > 0 && len(holidays) > f.limit {
+		holidays = holidays[:f.limit]
+	}
+	return scraper.Localize(holidays, f.lang)
+}
+
+// diffPrevious compares the -diff file's holidays with final, after running
+// them through the same consolidation and filters, so that holidays a filter
+// drops or -lang renames aren't reported as changes
+func diffPrevious(previous, final []scraper.Holiday, dropObservances bool, f filters) scraper.Diff {
+	previous = scraper.Consolidate(previous)
+	if dropObservances {
+		previous = scraper.FilterGazetted(previous)
+	}
+	return scraper.DiffHolidays(f.apply(previous), final)
+}
+
+// normalizeFormat checks format against formats() and returns it as the
+// file extension it's written with
+func normalizeFormat(format string) (string, error) {
+	f := strings.ToLower(format)
+	if !slices.Contains(formats(), f) {
+		return "", fmt.Errorf("%s (expected one of %s)", format, strings.Join(formats(), ", "))
+	}
+	if f == "markdown" {
+		return "md", nil
+	}
+	return f, nil
+}
+
+// outputOptions adjusts how writeOutput encodes holidays
+type outputOptions struct {
+	// envelope wraps JSON in a scraper.Envelope
+	envelope bool
+	// dateLayout reformats Date and the other dates; empty keeps YYYY-MM-DD
+	dateLayout string
+	// fileMode is the permission files are written with
+	fileMode os.FileMode
+	// gzip compresses whatever the format writes
+	gzip bool
+}
+
+// saveOutput writes holidays to the file at path in format
+func saveOutput(path, format string, holidays []scraper.Holiday, opts outputOptions) error {
+	// a database is upserted in place rather than streamed
+	if format == "sqlite" {
+		if err := scraper.SaveSQLite(path, holidays); err != nil {
+			return err
+		}
+		return os.Chmod(path, opts.fileMode)
+	}
+
+	return scraper.WriteFileAtomic(path, opts.fileMode, func(w io.Writer) error {
+		return writeCompressed(w, format, holidays, opts)
+	})
+}
+
+// writeCompressed is writeOutput through a gzip.Writer when opts.gzip is set
+func writeCompressed(w io.Writer, format string, holidays []scraper.Holiday, opts outputOptions) error {
+	if !opts.gzip {
+		return writeOutput(w, format, holidays, opts)
+	}
+	zw := gzip.NewWriter(w)
+	if err := writeOutput(zw, format, holidays, opts); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// saveRawRows writes a page's table cells to path as indented JSON, one
+// array of cells per row
+func saveRawRows(path string, rows [][]string) error {
+	if rows == nil {
+		rows = [][]string{}
+	}
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// printLongWeekends logs every long weekend and bridge opportunity in
+// holidays, one per line.
+func printLongWeekends(holidays []scraper.Holiday) {
+	spans := scraper.AnalyzeLongWeekends(holidays)
+	slog.Info(fmt.Sprintf("🏖️  %d long weekends and bridges", len(spans)), "count", len(spans))
+	for _, lw := range spans {
+		msg := fmt.Sprintf("   %s → %s (%d days): %s", lw.Start, lw.End, lw.Days, strings.Join(lw.Holidays, ", "))
+		if lw.Bridge != "" {
+			msg += fmt.Sprintf(" — take leave on %s", lw.Bridge)
+		}
+		slog.Info(msg, "start", lw.Start, "end", lw.End, "days", lw.Days, "holidays", lw.Holidays, "bridge", lw.Bridge)
+	}
+}
+
+// printStateComparison logs each holiday only some of states observe, with
+// a ✔/✘ column per state
+func printStateComparison(holidays []scraper.Holiday, states []string) {
+	diffs := scraper.CompareStates(holidays, states)
+	slog.Info(fmt.Sprintf("⚖️  %d holidays differ between %s", len(diffs), strings.Join(states, ", ")),
+		"states", states, "differences", len(diffs))
+	for _, d := range diffs {
+		var marks []string
+		for _, st := range states {
+			mark := "✘"
+			if slices.Contains(d.ObservedIn, st) {
+				mark = "✔"
+			}
+			marks = append(marks, mark+" "+st)
+		}
+		slog.Info(fmt.Sprintf("   %s %s: %s", d.Date, d.Name, strings.Join(marks, "  ")),
+			"date", d.Date, "name", d.Name, "observedIn", d.ObservedIn, "missingIn", d.MissingIn)
+	}
+}
+
+// printByMonth logs holidays under a header per month, one per line
+func printByMonth(holidays []scraper.Holiday) {
+	for _, g := range scraper.GroupByMonth(holidays) {
+		slog.Info(fmt.Sprintf("🗓️  %s (%d)", g.Label(), len(g.Holidays)), "month", g.Month, "holidays", len(g.Holidays))
+		for _, h := range g.Holidays {
+			slog.Info(fmt.Sprintf("   %s %-9s %s — %s", h.Date, h.Day, h.Name, strings.Join(h.States, ", ")),
+				"date", h.Date, "name", h.Name, "states", h.States)
+		}
+	}
+}
+
+// printSummary logs a short report on holidays: how many there are, how
+// many each state observes, how many fall on a weekend, and which fetches
+// in failures didn't succeed.
+func printSummary(holidays []scraper.Holiday, failures []string) {
+	perState := map[string]int{}
+	weekend := 0
+	for _, h := range holidays {
+		for _, st := range h.States {
+			perState[st]++
+		}
+		if h.OnWeekend {
+			weekend++
+		}
+	}
+
+	slog.Info(fmt.Sprintf("📊 %d holidays, %d on a weekend", len(holidays), weekend),
+		"holidays", len(holidays), "weekend", weekend)
+	states := make([]string, 0, len(perState))
+	for st := range perState {
+		states = append(states, st)
+	}
+	sort.Strings(states)
+	for _, st := range states {
+		slog.Info(fmt.Sprintf("   %-16s %d", st, perState[st]), "state", st, "holidays", perState[st])
+	}
+	if len(failures) > 0 {
+		slog.Info(fmt.Sprintf("   failed: %s", strings.Join(failures, ", ")), "failed", failures)
+	}
+}
+
+// failedStates names the fetches behind err, as "state (year)"
+func failedStates(err error) []string {
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else if err != nil {
+		errs = []error{err}
+	}
+
+	var out []string
+	for _, e := range errs {
+		var fe *scraper.FetchError
+		if errors.As(e, &fe) {
+			out = append(out, fmt.Sprintf("%s (%d)", fe.State, fe.Year))
+		}
+	}
+	return out
+}
+
+// printDiff logs what changed between the holidays in file and this run
+func printDiff(file string, d scraper.Diff) {
+	if d.Empty() {
+		slog.Info(fmt.Sprintf("🟰 No changes since %s", file), "file", file)
+		return
+	}
+
+	slog.Info(fmt.Sprintf("🔀 Changes since %s: %d added, %d removed, %d changed", file, len(d.Added), len(d.Removed), len(d.Changed)),
+		"file", file, "added", len(d.Added), "removed", len(d.Removed), "changed", len(d.Changed))
+	for _, h := range d.Added {
+		slog.Info(fmt.Sprintf("   + %s %s [%s]", h.Date, h.Name, strings.Join(h.States, ", ")),
+			"change", "added", "date", h.Date, "name", h.Name, "states", h.States)
+	}
+	for _, h := range d.Removed {
+		slog.Info(fmt.Sprintf("   - %s %s [%s]", h.Date, h.Name, strings.Join(h.States, ", ")),
+			"change", "removed", "date", h.Date, "name", h.Name, "states", h.States)
+	}
+	for _, c := range d.Changed {
+		msg := fmt.Sprintf("   ~ %s", c.New.Name)
+		if c.Old.Date != c.New.Date {
+			msg += fmt.Sprintf(" moved %s → %s", c.Old.Date, c.New.Date)
+		}
+		msg += fmt.Sprintf(" [%s] → [%s]", strings.Join(c.Old.States, ", "), strings.Join(c.New.States, ", "))
+		slog.Info(msg, "change", "changed", "name", c.New.Name,
+			"oldDate", c.Old.Date, "newDate", c.New.Date, "oldStates", c.Old.States, "newStates", c.New.States)
+	}
+}
+
+// writeOutput encodes holidays to w in format, which has already been
+// validated against formats.
+func writeOutput(w io.Writer, format string, holidays []scraper.Holiday, opts outputOptions) error {
+	if opts.dateLayout != "" {
+		holidays = scraper.ReformatDates(holidays, opts.dateLayout)
+	}
+	if format == "json" && opts.envelope {
+		return scraper.WriteJSONEnvelope(w, holidays)
+	}
+	fw, ok := scraper.LookupWriter(format)
+	if !ok {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+	return fw.Write(w, holidays)
+}
+
+// datePresets are the named -date-format layouts
+var datePresets = map[string]string{
+	"iso":  scraper.DateLayout,
+	"dmy":  "02/01/2006",
+	"mdy":  "01/02/2006",
+	"long": "2 Jan 2006",
+}
+
+// parseDateFormat resolves a -date-format preset, or checks that a custom
+// Go layout actually formats something
+func parseDateFormat(spec string) (string, error) {
+	if layout, ok := datePresets[strings.ToLower(spec)]; ok {
+		return layout, nil
+	}
+	sample := time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC)
+	if sample.Format(spec) == spec {
+		return "", fmt.Errorf("not a preset (iso, dmy, mdy, long) or a Go layout like 02.01.2006")
+	}
+	return spec, nil
+}
+
+// checkProxy makes sure proxy is a URL Chrome's --proxy-server understands
+func checkProxy(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks4", "socks5":
+	default:
+		return fmt.Errorf("unsupported scheme %q (expected http, https, socks4 or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	if u.User != nil {
+		return fmt.Errorf("Chrome does not support proxy credentials in the URL")
+	}
+	return nil
+}
+
+// parseYears accepts a single year ("2025"), an ascending range ("2023-2025")
+// or a comma-separated list of either ("2024,2026-2027"). The result is sorted
+// and de-duplicated.
+func parseYears(spec string) ([]int, error) {
+	seen := map[int]bool{}
+	var years []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
 			continue
 		}
+
+		from, to := part, part
+		if lo, hi, ok := strings.Cut(part, "-"); ok {
+			from, to = strings.TrimSpace(lo), strings.TrimSpace(hi)
+		}
+
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("bad year %q", from)
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, fmt.Errorf("bad year %q", to)
+		}
+		if start > end {
+			return nil, fmt.Errorf("descending range %q (did you mean %d-%d?)", part, end, start)
+		}
+
+		for y := start; y <= end; y++ {
+			if !seen[y] {
+				seen[y] = true
+				years = append(years, y)
+			}
+		}
+	}
+	if len(years) == 0 {
+		return nil, fmt.Errorf("no years given")
+	}
+	sort.Ints(years)
+	return years, nil
+}
+
+// loadMerge reads the JSON files at paths for -merge and returns their
+// holidays together with the years they cover, in order
+func loadMerge(paths []string) ([]scraper.Holiday, []int, error) {
+	var all []scraper.Holiday
+	seen := map[int]bool{}
+	for _, path := range paths {
+		holidays, err := scraper.LoadJSON(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading %s: %w", path, err)
+		}
+		slog.Info(fmt.Sprintf("📂 Loaded %d holidays from %s", len(holidays), path), "file", path, "holidays", len(holidays))
+		for _, h := range holidays {
+			if t, err := h.AsTime(); err == nil {
+				seen[t.Year()] = true
+			}
+		}
 		all = append(all, holidays...)
 	}
+	if len(seen) == 0 {
+		return nil, nil, fmt.Errorf("no dated holidays in %s", strings.Join(paths, ", "))
+	}
+	years := slices.Sorted(maps.Keys(seen))
+	return all, years, nil
+}
 
-	final := scraper.Consolidate(all)
+// yearFlag is -year: a year, or 0 when given as "current" to have the
+// latest published year looked up
+type yearFlag int
 
-	filename := fmt.Sprintf("%s-%d.%s", *out, *year, normalizedFormat)
-	var saveErr error
-	switch normalizedFormat {
-	case "json":
-		saveErr = scraper.SaveJSON(filename, final)
-	case "csv":
-		saveErr = scraper.SaveCSV(filename, final)
+func (y *yearFlag) String() string { return strconv.Itoa(int(*y)) }
+
+func (y *yearFlag) Set(v string) error {
+	if strings.EqualFold(v, "current") {
+		*y = 0
+		return nil
 	}
-	if saveErr != nil {
-		log.Fatal(saveErr)
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fmt.Errorf(`expected a year or "current"`)
 	}
-	log.Printf("✅ Holidays written to %s", filename)
+	*y = yearFlag(n)
+	return nil
 }
 
+// yearsLabel renders sorted years for the output filename: "2025" for a
+// single year, "2023-2025" for consecutive ones, and "2024_2026" when
+// there are gaps, so the name doesn't claim years that weren't fetched.
+func yearsLabel(years []int) string {
+	if len(years) == 1 {
+		return strconv.Itoa(years[0])
+	}
+	if years[len(years)-1]-years[0] == len(years)-1 {
+		return fmt.Sprintf("%d-%d", years[0], years[len(years)-1])
+	}
+	parts := make([]string, len(years))
+	for i, y := range years {
+		parts[i] = strconv.Itoa(y)
+	}
+	return strings.Join(parts, "_")
+}
+
+// parseStates splits a comma-separated list of state slugs or codes (SGR,
+// KUL, ...) and checks each one against valid, preserving the order given
+// by the user.
+func parseStates(spec string, valid []string) ([]string, error) {
+	known := map[string]bool{}
+	for _, st := range valid {
+		known[st] = true
+	}
+
+	seen := map[string]bool{}
+	var states []string
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		st, ok := scraper.ResolveState(part)
+		if !ok || !known[st] {
+			return nil, fmt.Errorf("unknown state %q (valid: %s; or a code: %s)",
+				part, strings.Join(valid, ", "), strings.Join(scraper.StateCodes(), ", "))
+		}
+		if !seen[st] {
+			seen[st] = true
+			states = append(states, st)
+		}
+	}
+	if len(states) == 0 {
+		return nil, fmt.Errorf("no states given")
+	}
+	return states, nil
+}
+
+// splitList splits a comma-separated flag value, trimming each item and
+// dropping empty ones
+func splitList(spec string) []string {
+	var out []string
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
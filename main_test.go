@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/farizkhoo/cuti-cli/scraper"
+)
+
+// roundTrip is holidays as -diff loads them back from a JSON file
+func roundTrip(t *testing.T, holidays []scraper.Holiday) []scraper.Holiday {
+	t.Helper()
+	data, err := json.Marshal(holidays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []scraper.Holiday
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestDiffPreviousFilters(t *testing.T) {
+	page, err := os.ReadFile("scraper/testdata/selangor-2025.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := scraper.ParseHolidays(string(page), "selangor", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scraped := scraper.FilterGazetted(scraper.Consolidate(parsed))
+
+	tests := []struct {
+		name string
+		f    filters
+	}{
+		{"month", filters{month: time.June, lang: scraper.LangEnglish}},
+		{"lang ms", filters{lang: scraper.LangMalay}},
+		{"month and lang ms", filters{month: time.March, lang: scraper.LangMalay}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			final := tt.f.apply(scraped)
+			// a file from a run without the filters, and one from the same run
+			for _, previous := range [][]scraper.Holiday{scraped, final} {
+				if d := diffPrevious(roundTrip(t, previous), final, true, tt.f); !d.Empty() {
+					t.Errorf("diff against unchanged data = %+v, want no changes", d)
+				}
+			}
+		})
+	}
+}
+
+func TestYearsLabel(t *testing.T) {
+	tests := []struct {
+		years []int
+		want  string
+	}{
+		{[]int{2025}, "2025"},
+		{[]int{2024, 2025}, "2024-2025"},
+		{[]int{2023, 2024, 2025}, "2023-2025"},
+		{[]int{2024, 2026}, "2024_2026"},
+		{[]int{2023, 2024, 2026}, "2023_2024_2026"},
+	}
+	for _, tt := range tests {
+		if got := yearsLabel(tt.years); got != tt.want {
+			t.Errorf("yearsLabel(%v) = %q, want %q", tt.years, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/farizkhoo/cuti-cli/scraper"
+)
+
+// fetchMetrics are the -metrics-port counters, fed by scraper.WithFetchStats
+type fetchMetrics struct {
+	registry *prometheus.Registry
+	scraped  *prometheus.CounterVec
+	failures *prometheus.CounterVec
+	cached   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newFetchMetrics() *fetchMetrics {
+	m := &fetchMetrics{
+		registry: prometheus.NewRegistry(),
+		scraped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "holidays_scraped_total",
+			Help: "Holidays read from state pages, before consolidation.",
+		}, []string{"state"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "state_fetch_failures_total",
+			Help: "State fetches that failed after retries, by reason.",
+		}, []string{"state", "reason"}),
+		cached: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "state_fetch_cache_hits_total",
+			Help: "State fetches answered from the holiday cache.",
+		}, []string{"state"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "state_fetch_duration_seconds",
+			Help:    "Time to fetch one state's page, retries included; cache hits aren't observed.",
+			Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120},
+		}, []string{"state"}),
+	}
+	m.registry.MustRegister(m.scraped, m.failures, m.cached, m.duration,
+		prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	return m
+}
+
+// observe records one finished fetch
+func (m *fetchMetrics) observe(st scraper.FetchStats) {
+	m.scraped.WithLabelValues(st.State).Add(float64(st.Holidays))
+	if st.Cached {
+		m.cached.WithLabelValues(st.State).Inc()
+		return
+	}
+	m.duration.WithLabelValues(st.State).Observe(st.Duration.Seconds())
+	if st.Err != nil {
+		m.failures.WithLabelValues(st.State, failureReason(st.Err)).Inc()
+	}
+}
+
+// failureReason is a short, fixed label for err
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, scraper.ErrNoRows):
+		return "no_rows"
+	case errors.Is(err, scraper.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, scraper.ErrInvalidHoliday):
+		return "invalid_holiday"
+	case errors.Is(err, scraper.ErrNavigation):
+		return "navigation"
+	}
+	return "other"
+}
+
+// serveMetrics exposes m on addr at /metrics in the background. It logs
+// rather than exits if the port can't be bound, since metrics are optional.
+func serveMetrics(addr string, m *fetchMetrics) {
+	slog.Info(fmt.Sprintf("📈 Serving metrics on http://%s/metrics", addr), "addr", addr)
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil {
+			slog.Error(fmt.Sprintf("⛔ Metrics server stopped: %v", err), "addr", addr, "error", err)
+		}
+	}()
+}
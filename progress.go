@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/farizkhoo/cuti-cli/scraper"
+)
+
+// progressBar draws a single self-updating status line on a terminal. It is
+// also an io.Writer, so log lines written through it are printed above the
+// bar instead of being overwritten by it.
+type progressBar struct {
+	mu   sync.Mutex
+	w    io.Writer
+	line string
+}
+
+func newProgressBar(w io.Writer) *progressBar {
+	return &progressBar{w: w}
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Update redraws the bar for p
+func (b *progressBar) Update(p scraper.Progress) {
+	const width = 24
+	filled := 0
+	if p.Total > 0 {
+		filled = width * p.Done / p.Total
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.line = fmt.Sprintf("🌐 [%s%s] %d/%d states (%d) · %s",
+		strings.Repeat("█", filled), strings.Repeat("░", width-filled), p.Done, p.Total, p.Year, p.State)
+	fmt.Fprintf(b.w, "\r\033[K%s", b.line)
+}
+
+// Write prints p on its own line(s) and redraws the bar underneath
+func (b *progressBar) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.line != "" {
+		fmt.Fprint(b.w, "\r\033[K")
+	}
+	n, err := b.w.Write(p)
+	if b.line != "" {
+		fmt.Fprint(b.w, b.line)
+	}
+	return n, err
+}
+
+// Finish ends the bar's line so later output starts on a fresh one
+func (b *progressBar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.line != "" {
+		fmt.Fprintln(b.w)
+		b.line = ""
+	}
+}
@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrNoBrowser means no Chrome or Chromium could be found or started
+var ErrNoBrowser = errors.New("no usable Chrome browser")
+
+// chromeCandidates are the names and paths FindChrome tries, following
+// chromedp's own search so both agree on which browser runs
+func chromeCandidates() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Google Chrome Canary.app/Contents/MacOS/Google Chrome Canary",
+			"google-chrome",
+			"chromium",
+			"headless-shell",
+			"headless_shell",
+		}
+	case "windows":
+		return []string{
+			"chrome",
+			"chrome.exe",
+			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+			filepath.Join(os.Getenv("USERPROFILE"), `AppData\Local\Google\Chrome\Application\chrome.exe`),
+			filepath.Join(os.Getenv("USERPROFILE"), `AppData\Local\Chromium\Application\chrome.exe`),
+		}
+	}
+	return []string{
+		"headless_shell",
+		"headless-shell",
+		"chromium",
+		"chromium-browser",
+		"google-chrome",
+		"google-chrome-stable",
+		"google-chrome-beta",
+		"google-chrome-unstable",
+		"/usr/bin/google-chrome",
+		"/usr/local/bin/chrome",
+		"/snap/bin/chromium",
+		"chrome",
+	}
+}
+
+// FindChrome returns the browser executable StartScraper would run: path,
+// if it's not empty, or else the first Chrome or Chromium found on PATH or
+// in its usual install locations. The error wraps ErrNoBrowser.
+func FindChrome(path string) (string, error) {
+	if path != "" {
+		found, err := exec.LookPath(path)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s is not an executable: %w", ErrNoBrowser, path, err)
+		}
+		return found, nil
+	}
+	for _, name := range chromeCandidates() {
+		if found, err := exec.LookPath(name); err == nil {
+			return found, nil
+		}
+	}
+	return "", fmt.Errorf("%w: Chrome or Chromium isn't installed, or isn't on PATH (tried %s)",
+		ErrNoBrowser, strings.Join(chromeCandidates(), ", "))
+}
@@ -0,0 +1,32 @@
+package scraper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestFindChromePath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the browser")
+	}
+	fake := filepath.Join(t.TempDir(), "chrome")
+	if err := os.WriteFile(fake, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := FindChrome(fake); err != nil || got != fake {
+		t.Errorf("FindChrome(%s) = %q, %v", fake, got, err)
+	}
+	if _, err := FindChrome(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, ErrNoBrowser) {
+		t.Errorf("FindChrome(missing) error = %v, want ErrNoBrowser", err)
+	}
+
+	// found, but exits instead of starting
+	s, err := StartScraper(WithChromePath(fake), WithTimeout(5*time.Second))
+	if !errors.Is(err, ErrNoBrowser) || s != nil {
+		t.Errorf("StartScraper with a broken browser = %v, %v; want ErrNoBrowser", s, err)
+	}
+}
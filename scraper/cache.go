@@ -0,0 +1,106 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The cache directory holds two files per state+year: an HTML snapshot of
+// the page (<state>-<year>.html) and the holidays parsed from it
+// (<state>-<year>.json). The parsed entry is checked first so a re-run
+// skips the browser entirely for states it already has.
+
+// cacheEntry is the on-disk form of a parsed state page
+type cacheEntry struct {
+	State     string    `json:"state"`
+	Year      int       `json:"year"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Holidays  []Holiday `json:"holidays"`
+}
+
+// snapshotPath is where the page for state+year is cached, or "" when
+// no cache directory is set.
+func (s *Scraper) snapshotPath(state string, year int) string {
+	if s.cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(s.cacheDir, fmt.Sprintf("%s-%d.html", state, year))
+}
+
+func (s *Scraper) entryPath(state string, year int) string {
+	return filepath.Join(s.cacheDir, fmt.Sprintf("%s-%d.json", state, year))
+}
+
+func saveSnapshot(path, html string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(html), 0644)
+}
+
+// fresh reports whether the cached file at path may be used: it exists,
+// refresh wasn't requested and it is younger than the max age, if any.
+func (s *Scraper) fresh(path string) bool {
+	if s.refresh {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return s.maxAge <= 0 || time.Since(info.ModTime()) < s.maxAge
+}
+
+// loadCached returns the parsed holidays cached for state+year, if any
+func (s *Scraper) loadCached(state string, year int) ([]Holiday, bool) {
+	if s.cacheDir == "" || s.refresh {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(s.entryPath(state, year))
+	if err != nil {
+		return nil, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Ignoring corrupt cache entry for %s (%d): %v", state, year, err),
+			"state", state, "year", year, "error", err)
+		return nil, false
+	}
+	if s.maxAge > 0 && time.Since(e.FetchedAt) >= s.maxAge {
+		return nil, false
+	}
+
+	slog.Info(fmt.Sprintf("📦 Using %d cached rows for %s (%d) from %s", len(e.Holidays), state, year, e.FetchedAt.Format(time.RFC3339)),
+		"state", state, "year", year, "rows", len(e.Holidays), "fetchedAt", e.FetchedAt)
+	return e.Holidays, true
+}
+
+// saveCached records holidays for state+year. Empty results aren't cached,
+// since they usually mean the page didn't render properly.
+func (s *Scraper) saveCached(state string, year int, holidays []Holiday) {
+	if s.cacheDir == "" || len(holidays) == 0 {
+		return
+	}
+
+	data, err := json.MarshalIndent(cacheEntry{
+		State:     state,
+		Year:      year,
+		FetchedAt: time.Now().UTC(),
+		Holidays:  holidays,
+	}, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(s.cacheDir, 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(s.entryPath(state, year), data, 0644)
+	}
+	if err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Could not cache %s (%d): %v", state, year, err),
+			"state", state, "year", year, "error", err)
+	}
+}
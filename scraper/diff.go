@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"slices"
+	"strings"
+)
+
+// Diff is the difference between two holiday datasets
+type Diff struct {
+	Added   []Holiday `json:"added"`
+	Removed []Holiday `json:"removed"`
+	Changed []Change  `json:"changed"`
+}
+
+// Change is a holiday present in both datasets whose date or states differ
+type Change struct {
+	Old Holiday `json:"old"`
+	New Holiday `json:"new"`
+}
+
+// Empty reports whether the datasets were identical
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffHolidays compares old against new. Holidays are matched by year and
+// normalized name, so a holiday that moved to another date shows up as
+// changed rather than as one removal plus one addition. Same-named holidays
+// within a year (e.g. the two days of Hari Raya) are paired in date order.
+func DiffHolidays(old, new []Holiday) Diff {
+	oldBy := groupForDiff(old)
+	newBy := groupForDiff(new)
+
+	var d Diff
+	for key, olds := range oldBy {
+		news := newBy[key]
+		for i, o := range olds {
+			if i >= len(news) {
+				d.Removed = append(d.Removed, o)
+				continue
+			}
+			n := news[i]
+			if o.Date != n.Date || !sameStates(o.States, n.States) {
+				d.Changed = append(d.Changed, Change{Old: o, New: n})
+			}
+		}
+	}
+	for key, news := range newBy {
+		if n := len(oldBy[key]); n < len(news) {
+			d.Added = append(d.Added, news[n:]...)
+		}
+	}
+
+	slices.SortFunc(d.Added, compareDiff)
+	slices.SortFunc(d.Removed, compareDiff)
+	slices.SortFunc(d.Changed, func(a, b Change) int { return compareDiff(a.New, b.New) })
+	return d
+}
+
+func groupForDiff(holidays []Holiday) map[string][]Holiday {
+	by := map[string][]Holiday{}
+	for _, h := range holidays {
+		year, _, _ := strings.Cut(h.Date, "-")
+		key := year + "|" + nameKey(h.Name)
+		by[key] = append(by[key], h)
+	}
+	for _, hs := range by {
+		slices.SortFunc(hs, compareDiff)
+	}
+	return by
+}
+
+func compareDiff(a, b Holiday) int {
+	if c := strings.Compare(a.Date, b.Date); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+func sameStates(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// StateDifference is a holiday only some of the compared states observe
+type StateDifference struct {
+	Date       string   `json:"date"`
+	Name       string   `json:"name"`
+	ObservedIn []string `json:"observedIn"`
+	MissingIn  []string `json:"missingIn"`
+}
+
+// CompareStates lists the consolidated holidays that some but not all of
+// states observe, in the order holidays are in, with each side's states in
+// the order given. Holidays every state or no state observes are left out.
+func CompareStates(holidays []Holiday, states []string) []StateDifference {
+	var out []StateDifference
+	for _, h := range holidays {
+		d := StateDifference{Date: h.Date, Name: h.Name}
+		for _, st := range states {
+			if slices.Contains(h.States, st) {
+				d.ObservedIn = append(d.ObservedIn, st)
+			} else {
+				d.MissingIn = append(d.MissingIn, st)
+			}
+		}
+		if len(d.ObservedIn) > 0 && len(d.MissingIn) > 0 {
+			out = append(out, d)
+		}
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffHolidays(t *testing.T) {
+	old := []Holiday{
+		{Date: "2025-01-01", Name: "New Year's Day", States: []string{"selangor"}},
+		{Date: "2025-02-01", Name: "Federal Territory Day", States: []string{"kuala-lumpur"}},
+		{Date: "2025-10-20", Name: "Deepavali", States: []string{"johor"}},
+	}
+	new := []Holiday{
+		{Date: "2025-01-01", Name: "New Year's Day", States: []string{"selangor", "johor"}},
+		{Date: "2025-10-21", Name: "Deepavali", States: []string{"johor"}},
+		{Date: "2025-12-25", Name: "Christmas Day", States: []string{"johor"}},
+	}
+
+	d := DiffHolidays(old, new)
+	if len(d.Added) != 1 || d.Added[0].Name != "Christmas Day" {
+		t.Errorf("Added = %+v, want Christmas Day", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Name != "Federal Territory Day" {
+		t.Errorf("Removed = %+v, want Federal Territory Day", d.Removed)
+	}
+	if len(d.Changed) != 2 {
+		t.Fatalf("Changed = %+v, want New Year's Day (states) and Deepavali (date)", d.Changed)
+	}
+	if d.Changed[0].New.Name != "New Year's Day" || d.Changed[1].New.Date != "2025-10-21" {
+		t.Errorf("Changed = %+v", d.Changed)
+	}
+}
+
+func TestCompareStates(t *testing.T) {
+	holidays := []Holiday{
+		{Date: "2025-01-01", Name: "New Year's Day", States: []string{"kuala-lumpur", "selangor"}},
+		{Date: "2025-02-01", Name: "Federal Territory Day", States: []string{"kuala-lumpur"}},
+		{Date: "2025-03-23", Name: "Sultan of Johor's Birthday", States: []string{"johor"}},
+		{Date: "2025-03-31", Name: "Hari Raya Aidilfitri", States: []string{"johor", "kuala-lumpur", "selangor"}},
+	}
+	got := CompareStates(holidays, []string{"selangor", "kuala-lumpur"})
+	want := []StateDifference{
+		{Date: "2025-02-01", Name: "Federal Territory Day", ObservedIn: []string{"kuala-lumpur"}, MissingIn: []string{"selangor"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareStates() =\n%+v\nwant\n%+v", got, want)
+	}
+}
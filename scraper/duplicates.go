@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"sort"
+	"strings"
+)
+
+// DateConflict is a date on which States each list the same set of
+// distinct holidays after consolidation. That can be genuine (a state
+// holiday coinciding with a federal one) or a parsing artifact.
+type DateConflict struct {
+	Date   string
+	Names  []string
+	States []string
+}
+
+// FindDateConflicts reports every date that more than one holiday in
+// holidays claims for the same state, ordered by date. States sharing the
+// same clash are reported together. Call it on Consolidate's output so
+// near-identical names are already merged.
+func FindDateConflicts(holidays []Holiday) []DateConflict {
+	perState := make(map[[2]string][]string)
+	for _, h := range holidays {
+		for _, st := range h.States {
+			key := [2]string{h.Date, st}
+			perState[key] = append(perState[key], h.Name)
+		}
+	}
+
+	grouped := make(map[string]*DateConflict)
+	for key, names := range perState {
+		if names = unique(names); len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		id := key[0] + "|" + strings.Join(names, "|")
+		if grouped[id] == nil {
+			grouped[id] = &DateConflict{Date: key[0], Names: names}
+		}
+		grouped[id].States = append(grouped[id].States, key[1])
+	}
+
+	out := make([]DateConflict, 0, len(grouped))
+	for _, c := range grouped {
+		sort.Strings(c.States)
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date < out[j].Date
+		}
+		return strings.Join(out[i].Names, "|") < strings.Join(out[j].Names, "|")
+	})
+	return out
+}
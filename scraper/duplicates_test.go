@@ -0,0 +1,23 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDateConflicts(t *testing.T) {
+	holidays := []Holiday{
+		{Date: "2025-03-31", Name: "Hari Raya Aidilfitri", States: []string{"johor", "selangor"}},
+		{Date: "2025-03-31", Name: "Sultan of Johor's Birthday", States: []string{"johor"}},
+		{Date: "2025-05-01", Name: "Labour Day", States: []string{"johor", "selangor"}},
+	}
+	got := FindDateConflicts(holidays)
+	want := []DateConflict{{
+		Date:   "2025-03-31",
+		Names:  []string{"Hari Raya Aidilfitri", "Sultan of Johor's Birthday"},
+		States: []string{"johor"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindDateConflicts = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package scraper
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// SchemaVersion identifies the shape of Holiday in JSON output. Bump it
+// whenever a field is added, removed or changes meaning.
+const SchemaVersion = 6
+
+// Envelope wraps JSON output so consumers can tell which release wrote it
+type Envelope struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	GeneratedAt   time.Time `json:"generatedAt"`
+	Holidays      []Holiday `json:"holidays"`
+}
+
+// SaveJSONEnvelope saves holidays to path wrapped in an Envelope
+func SaveJSONEnvelope(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteJSONEnvelope)
+}
+
+// WriteJSONEnvelope writes holidays to w as indented JSON wrapped in an
+// Envelope stamped with the current time.
+func WriteJSONEnvelope(w io.Writer, holidays []Holiday) error {
+	env := Envelope{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now().UTC().Truncate(time.Second),
+		Holidays:      holidays,
+	}
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,124 @@
+package scraper
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// FilterByMonth returns the holidays falling in month, in any year.
+// Holidays with unparseable dates are dropped.
+func FilterByMonth(holidays []Holiday, month time.Month) []Holiday {
+	var out []Holiday
+	for _, h := range holidays {
+		if t, err := h.AsTime(); err == nil && t.Month() == month {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// FilterByState returns the holidays observed in state, which may be a slug,
+// a code like SGR, or a name like "Kuala Lumpur".
+func FilterByState(holidays []Holiday, state string) []Holiday {
+	state = normalizeState(state)
+	var out []Holiday
+	for _, h := range holidays {
+		if slices.Contains(h.States, state) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// FilterByDateRange returns the holidays from from through to, inclusive.
+// Only the calendar date of each bound matters. Holidays with unparseable
+// dates are dropped.
+func FilterByDateRange(holidays []Holiday, from, to time.Time) []Holiday {
+	lo, hi := from.Format(DateLayout), to.Format(DateLayout)
+	var out []Holiday
+	for _, h := range holidays {
+		if _, err := h.AsTime(); err == nil && h.Date >= lo && h.Date <= hi {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// FilterUpcoming returns the holidays on or after now's calendar date, in
+// date order. Holidays with unparseable dates are dropped.
+func FilterUpcoming(holidays []Holiday, now time.Time) []Holiday {
+	today := now.Format(DateLayout)
+	var out []Holiday
+	for _, h := range holidays {
+		if _, err := h.AsTime(); err == nil && h.Date >= today {
+			out = append(out, h)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return dateLess(out[i], out[j]) })
+	return out
+}
+
+// FilterGazetted drops observances, keeping official days off
+func FilterGazetted(holidays []Holiday) []Holiday {
+	var out []Holiday
+	for _, h := range holidays {
+		if h.Gazetted() {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// FilterWeekdays drops holidays that fall on a weekend
+func FilterWeekdays(holidays []Holiday) []Holiday {
+	var out []Holiday
+	for _, h := range holidays {
+		if !h.OnWeekend {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// FilterNational keeps holidays observed nationwide: those listed for
+// National, or for at least minStates of AllStates. minStates <= 0 means
+// all of them; a majority is len(AllStates())/2 + 1.
+func FilterNational(holidays []Holiday, minStates int) []Holiday {
+	all := AllStates()
+	if minStates <= 0 {
+		minStates = len(all)
+	}
+	var out []Holiday
+	for _, h := range holidays {
+		n := 0
+		for _, st := range h.States {
+			if slices.Contains(all, st) {
+				n++
+			}
+		}
+		if n >= minStates || slices.Contains(h.States, National) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// FilterByNames returns the holidays whose name is one of names. Both sides
+// are canonicalized first, so an alias like "Hari Raya Puasa" keeps Hari
+// Raya Aidilfitri, and case, spacing and apostrophes don't matter. A
+// second day such as "Chinese New Year Holiday" has a name of its own and
+// must be listed to be kept.
+func FilterByNames(holidays []Holiday, names []string) []Holiday {
+	keep := map[string]bool{}
+	for _, n := range names {
+		keep[aliasKey(CanonicalizeName(n))] = true
+	}
+	var out []Holiday
+	for _, h := range holidays {
+		if keep[aliasKey(CanonicalizeName(h.Name))] || (h.NameMs != "" && keep[aliasKey(h.NameMs)]) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var filterHolidays = []Holiday{
+	{Date: "2025-01-01", Name: "New Year's Day", States: []string{"kuala-lumpur", "selangor"}},
+	{Date: "2025-02-01", Name: "Federal Territory Day", States: []string{"kuala-lumpur"}},
+	{Date: "2025-03-31", Name: "Hari Raya Aidilfitri", States: []string{"johor", "kuala-lumpur", "selangor"}},
+}
+
+func dates(holidays []Holiday) []string {
+	var out []string
+	for _, h := range holidays {
+		out = append(out, h.Date)
+	}
+	return out
+}
+
+func TestFilterByState(t *testing.T) {
+	tests := []struct {
+		state string
+		want  []string
+	}{
+		{"selangor", []string{"2025-01-01", "2025-03-31"}},
+		{"KUL", []string{"2025-01-01", "2025-02-01", "2025-03-31"}},
+		{"Johor", []string{"2025-03-31"}},
+		{"sabah", nil},
+	}
+	for _, tt := range tests {
+		if got := dates(FilterByState(filterHolidays, tt.state)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterByState(%q) = %v, want %v", tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestFilterByDateRange(t *testing.T) {
+	from := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)
+	want := []string{"2025-02-01", "2025-03-31"}
+	if got := dates(FilterByDateRange(filterHolidays, from, to)); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterByDateRange = %v, want %v", got, want)
+	}
+}
+
+func TestFilterByMonth(t *testing.T) {
+	want := []string{"2025-02-01"}
+	if got := dates(FilterByMonth(filterHolidays, time.February)); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterByMonth = %v, want %v", got, want)
+	}
+}
+
+func TestFilterUpcoming(t *testing.T) {
+	// late in the day still counts today's holiday as upcoming
+	now := time.Date(2025, 2, 1, 23, 30, 0, 0, time.Local)
+	want := []string{"2025-02-01", "2025-03-31"}
+	if got := dates(FilterUpcoming(filterHolidays, now)); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterUpcoming = %v, want %v", got, want)
+	}
+}
+
+func TestFilterNational(t *testing.T) {
+	holidays := append([]Holiday{
+		{Date: "2025-08-31", Name: "National Day", States: AllStates()},
+		{Date: "2025-09-16", Name: "Malaysia Day", States: []string{National}},
+		{Date: "2025-10-20", Name: "Deepavali", States: AllStates()[1:]},
+	}, filterHolidays...)
+
+	tests := []struct {
+		min  int
+		want []string
+	}{
+		{0, []string{"2025-08-31", "2025-09-16"}},
+		{len(AllStates())/2 + 1, []string{"2025-08-31", "2025-09-16", "2025-10-20"}},
+		{2, []string{"2025-08-31", "2025-09-16", "2025-10-20", "2025-01-01", "2025-03-31"}},
+	}
+	for _, tt := range tests {
+		if got := dates(FilterNational(holidays, tt.min)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterNational(%d) = %v, want %v", tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestFilterByNames(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"hari raya puasa"}, []string{"2025-03-31"}},
+		{[]string{"NEW YEARS DAY", "  Federal   Territory Day "}, []string{"2025-01-01", "2025-02-01"}},
+		{[]string{"Hari Wilayah"}, []string{"2025-02-01"}},
+		{[]string{"Hari Raya"}, nil},
+	}
+	for _, tt := range tests {
+		if got := dates(FilterByNames(filterHolidays, tt.names)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterByNames(%q) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+}
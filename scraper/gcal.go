@@ -0,0 +1,152 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// gcalMarker tags events PushGoogleCalendar creates, so pruning only ever
+// touches those
+const gcalMarker = "cuti-cli"
+
+// GCalResult counts what PushGoogleCalendar did
+type GCalResult struct {
+	Created, Updated, Deleted int
+}
+
+// PushGoogleCalendar upserts holidays as all-day events in the Google
+// Calendar calendarID, authenticating with the service account or
+// authorized user JSON at credentialsFile. Each event's ID is derived from
+// the holiday's date and name, so re-running updates rather than
+// duplicates. With prune, events this tool created earlier in the same
+// years that no longer match a holiday are deleted.
+func PushGoogleCalendar(ctx context.Context, credentialsFile, calendarID string, holidays []Holiday, prune bool) (GCalResult, error) {
+	var res GCalResult
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return res, err
+	}
+	creds, err := google.CredentialsFromJSON(ctx, data, calendar.CalendarEventsScope)
+	if err != nil {
+		return res, fmt.Errorf("reading credentials: %w", err)
+	}
+	svc, err := calendar.NewService(ctx, option.WithCredentials(creds))
+	if err != nil {
+		return res, err
+	}
+
+	keep := make(map[string]bool, len(holidays))
+	var errs []error
+	for _, h := range holidays {
+		ev := gcalEvent(h)
+		keep[ev.Id] = true
+
+		// Update also revives an event a user deleted, which Insert rejects
+		_, err := svc.Events.Update(calendarID, ev.Id, ev).Context(ctx).Do()
+		if gcalStatus(err) == http.StatusNotFound {
+			_, err = svc.Events.Insert(calendarID, ev).Context(ctx).Do()
+			if err == nil {
+				res.Created++
+			}
+		} else if err == nil {
+			res.Updated++
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %q: %w", h.Date, h.Name, err))
+		}
+	}
+
+	if prune && len(holidays) > 0 {
+		deleted, err := pruneGCal(ctx, svc, calendarID, holidays, keep)
+		res.Deleted = deleted
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return res, errors.Join(errs...)
+}
+
+// pruneGCal deletes this tool's events within the years holidays cover
+// whose IDs aren't in keep
+func pruneGCal(ctx context.Context, svc *calendar.Service, calendarID string, holidays []Holiday, keep map[string]bool) (int, error) {
+	first, last := holidays[0].Date, holidays[0].Date
+	for _, h := range holidays {
+		first, last = min(first, h.Date), max(last, h.Date)
+	}
+	lo, err := time.Parse(DateLayout, first[:4]+"-01-01")
+	if err != nil {
+		return 0, err
+	}
+	hi, err := time.Parse(DateLayout, last[:4]+"-01-01")
+	if err != nil {
+		return 0, err
+	}
+	hi = hi.AddDate(1, 0, 0)
+
+	deleted := 0
+	err = svc.Events.List(calendarID).
+		PrivateExtendedProperty("source="+gcalMarker).
+		TimeMin(lo.Format(time.RFC3339)).
+		TimeMax(hi.Format(time.RFC3339)).
+		Pages(ctx, func(page *calendar.Events) error {
+			for _, ev := range page.Items {
+				if keep[ev.Id] {
+					continue
+				}
+				if err := svc.Events.Delete(calendarID, ev.Id).Context(ctx).Do(); err != nil {
+					return fmt.Errorf("deleting %s: %w", ev.Summary, err)
+				}
+				slog.Info(fmt.Sprintf("🗑️  Removed %s %q from Google Calendar", ev.Start.Date, ev.Summary),
+					"date", ev.Start.Date, "name", ev.Summary)
+				deleted++
+			}
+			return nil
+		})
+	return deleted, err
+}
+
+// gcalEvent turns h into an all-day event with a stable ID
+func gcalEvent(h Holiday) *calendar.Event {
+	end := h.Date
+	if t, err := h.AsTime(); err == nil {
+		end = t.AddDate(0, 0, 1).Format(DateLayout)
+	}
+	return &calendar.Event{
+		Id:           gcalID(h),
+		Summary:      h.Name,
+		Description:  "States: " + strings.Join(h.States, ", "),
+		Start:        &calendar.EventDateTime{Date: h.Date},
+		End:          &calendar.EventDateTime{Date: end},
+		Transparency: "transparent",
+		Status:       "confirmed",
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{"source": gcalMarker},
+		},
+	}
+}
+
+// gcalID is h's ID as an event ID. Calendar IDs may only use base32hex
+// characters (0-9, a-v), which hex digits satisfy.
+func gcalID(h Holiday) string {
+	return "cuti" + h.ID()
+}
+
+// gcalStatus is the HTTP status of a Calendar API error, or 0
+func gcalStatus(err error) int {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
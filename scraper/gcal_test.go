@@ -0,0 +1,23 @@
+package scraper
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGCalEvent(t *testing.T) {
+	h := Holiday{Date: "2025-12-31", Name: "New Year's Eve", States: []string{"johor", "selangor"}}
+	ev := gcalEvent(h)
+
+	// Calendar only accepts base32hex IDs of at least 5 characters
+	if !regexp.MustCompile(`^[0-9a-v]{5,}$`).MatchString(ev.Id) {
+		t.Errorf("event ID %q is not valid base32hex", ev.Id)
+	}
+	if ev.Start.Date != "2025-12-31" || ev.End.Date != "2026-01-01" {
+		t.Errorf("event runs %s to %s, want 2025-12-31 to 2026-01-01", ev.Start.Date, ev.End.Date)
+	}
+	// the ID survives spelling noise so re-runs update the same event
+	if other := gcalEvent(Holiday{Date: h.Date, Name: "New Years Eve"}); other.Id != ev.Id {
+		t.Errorf("IDs differ for the same holiday: %s vs %s", ev.Id, other.Id)
+	}
+}
@@ -0,0 +1,46 @@
+package scraper
+
+import (
+	"fmt"
+	"time"
+)
+
+// HijriDate is a date in the tabular Islamic calendar
+type HijriDate struct {
+	Year, Month, Day int
+}
+
+// String formats d as YYYY-MM-DD, like Holiday.Date
+func (d HijriDate) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// ToHijri converts t's calendar date to the tabular (arithmetic) Islamic
+// calendar, with leap years 2, 5, 7, 10, 13, 16, 18, 21, 24, 26 and 29 of
+// each 30-year cycle. Malaysia starts its months on the sighting of the new
+// moon instead, so the official date can be a day either side of this one.
+func ToHijri(t time.Time) HijriDate {
+	// Julian day number of the date, then the standard conversion counting
+	// from 1 Muharram 1 AH (JDN 1948440)
+	jd := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix()/86400) + 2440588
+	l := jd - 1948440 + 10632
+	n := (l - 1) / 10631
+	l = l - 10631*n + 354
+	j := ((10985-l)/5316)*((50*l)/17719) + (l/5670)*((43*l)/15238)
+	l = l - ((30-j)/15)*((17719*j)/50) - (j/16)*((15238*j)/43) + 29
+	m := (24 * l) / 709
+	return HijriDate{Year: 30*n + j - 30, Month: m, Day: l - (709*m)/24}
+}
+
+// AddHijriDates returns holidays with HijriDate set from each Date. Dates
+// that don't parse are left without one.
+func AddHijriDates(holidays []Holiday) []Holiday {
+	out := make([]Holiday, len(holidays))
+	for i, h := range holidays {
+		if t, err := h.AsTime(); err == nil {
+			h.HijriDate = ToHijri(t).String()
+		}
+		out[i] = h
+	}
+	return out
+}
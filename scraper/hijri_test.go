@@ -0,0 +1,33 @@
+package scraper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToHijri(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		// the calendar's epoch, 16 July 622 Julian
+		{"0622-07-19", "0001-01-01"},
+		{"2025-03-30", "1446-09-30"},
+		// Hari Raya Aidilfitri, 1 Syawal, fell on the tabular date
+		{"2025-03-31", "1446-10-01"},
+		// Awal Muharram was sighted a day before the tabular calendar has it
+		{"2024-07-07", "1445-12-30"},
+		{"2024-07-08", "1446-01-01"},
+		// Hari Raya Haji, 10 Zulhijjah, fell on the tabular date
+		{"2025-06-07", "1446-12-10"},
+	}
+	for _, tt := range tests {
+		d, err := time.Parse(DateLayout, tt.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ToHijri(d).String(); got != tt.want {
+			t.Errorf("ToHijri(%s) = %s, want %s", tt.date, got, tt.want)
+		}
+	}
+}
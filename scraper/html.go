@@ -0,0 +1,307 @@
+package scraper
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultYearHeader is the pattern headings are matched against to find a
+// year's table; {year} stands for the year
+const DefaultYearHeader = `\b{year}\b`
+
+// headings are the heading levels searched for the year, in order of
+// preference: the site uses h2, but a redesign may not
+var headings = []string{"h2", "h3", "h1"}
+
+// layout says how to find a year's holiday table on a page
+type layout struct {
+	// selector matches the table; empty accepts any table
+	selector string
+	// yearHeader matches a heading naming the year, {year} standing for
+	// it. Empty means DefaultYearHeader.
+	yearHeader string
+}
+
+// defaultLayout is the site's layout as the scraper expects it by default
+var defaultLayout = layout{selector: DefaultTableSelector}
+
+// yearHeaderRegexp compiles l's heading pattern for year
+func (l layout) yearHeaderRegexp(year int) (*regexp.Regexp, error) {
+	pattern := cmp.Or(l.yearHeader, DefaultYearHeader)
+	return regexp.Compile(strings.ReplaceAll(pattern, "{year}", strconv.Itoa(year)))
+}
+
+// CheckYearHeader reports whether pattern is usable with
+// WithYearHeaderRegex
+func CheckYearHeader(pattern string) error {
+	if !strings.Contains(pattern, "{year}") {
+		return fmt.Errorf("pattern must contain {year}")
+	}
+	_, err := layout{yearHeader: pattern}.yearHeaderRegexp(2025)
+	return err
+}
+
+// ParseHolidays reads state's holidays for year out of the HTML of its page,
+// for callers that fetch pages themselves (through a proxy, or from an
+// archive). It is the parsing fetchState does once Chrome has loaded a page:
+// a page without the holiday table gives an error wrapping ErrNoRows, one
+// that can't be read at all wraps ErrParse, and bad rows are skipped and
+// reported as ParseRows does. Nothing is fetched, so errors never wrap
+// ErrNavigation or ErrTimeout.
+func ParseHolidays(html, state string, year int) ([]Holiday, error) {
+	return parseHTML(html, state, year, defaultLayout, nil)
+}
+
+// parseHTML is ParseHolidays with the layout fetchState was configured
+// with. onRows, if not nil, gets the extracted cells before they are parsed.
+func parseHTML(html, state string, year int, l layout, onRows func([][]string)) ([]Holiday, error) {
+	rows, found, err := extractRows(html, year, l)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s (%d): %w", ErrParse, state, year, err)
+	}
+	if onRows != nil {
+		onRows(rows)
+	}
+	// h2 under the default pattern is the expected case; anything else is
+	// worth knowing when the page changes
+	if found.header != nil && (found.level != "h2" || l.yearHeader != "") {
+		slog.Info(fmt.Sprintf("🔎 Matched %s %q for %s (%d)", found.level, found.header.Text(), state, year),
+			"state", state, "year", year, "heading", found.level, "text", found.header.Text())
+	}
+	if found.wrongYear != "" {
+		slog.Warn(fmt.Sprintf("📅 The table after the %d header for %s is for %s; ignoring it", year, state, found.wrongYear),
+			"state", state, "year", year, "tableYear", found.wrongYear)
+		return nil, fmt.Errorf("%w for %s in %d; the only table found is for %s", ErrNoRows, state, year, found.wrongYear)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w for %s in %d; page may have changed", ErrNoRows, state, year)
+	}
+	return ParseRows(rows, state, year)
+}
+
+// extractRows takes the table findYearTable finds for year and returns
+// each body row's cell text with whitespace collapsed. A three-cell row
+// styled as an observance gets TypeObservance as a fourth cell. A page
+// without such a table gives no rows and no error; found says what was
+// matched, including a table for another year.
+func extractRows(page string, year int, l layout) (rows [][]string, found yearTable, err error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return nil, found, err
+	}
+	if found, err = findYearTable(doc, year, l); err != nil || found.table == nil {
+		return nil, found, err
+	}
+
+	found.table.Find("tbody tr").Each(func(_ int, tr *goquery.Selection) {
+		var cells []string
+		tr.Find("td").Each(func(_ int, td *goquery.Selection) {
+			// footnote numbers in <sup> would otherwise run into the name
+			td.Find("sup").Each(func(_ int, sup *goquery.Selection) {
+				sup.ReplaceWithHtml(html.EscapeString(superscripts.Replace(strings.TrimSpace(sup.Text()))))
+			})
+			cells = append(cells, strings.Join(strings.Fields(td.Text()), " "))
+		})
+		if class, _ := tr.Attr("class"); len(cells) == 3 && observanceClass.MatchString(class) {
+			cells = append(cells, TypeObservance)
+		}
+		rows = append(rows, cells)
+	})
+	return rows, found, nil
+}
+
+// yearPattern finds the years a heading or caption mentions
+var yearPattern = regexp.MustCompile(`\b(?:19|20)\d{2}\b`)
+
+// asciiDigits rewrites full-width and Arabic-Indic digits as ASCII, so a
+// heading like "２０２５" still names 2025
+var asciiDigits = strings.NewReplacer(
+	"０", "0", "１", "1", "２", "2", "３", "3", "４", "4", "５", "5", "６", "6", "７", "7", "８", "8", "９", "9",
+	"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4", "٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+)
+
+// headingText is a heading's text with its digits made ASCII
+func headingText(h *goquery.Selection) string {
+	return asciiDigits.Replace(h.Text())
+}
+
+// yearTable is what findYearTable matched
+type yearTable struct {
+	// header is the heading naming the year, and level its tag
+	header *goquery.Selection
+	level  string
+	// table is the year's holiday table; wrongYear is the year the next
+	// table was for instead, when it wasn't this one
+	table     *goquery.Selection
+	wrongYear string
+}
+
+// findYearTable finds the headings whose text matches l's year pattern,
+// trying h2 before h3 and h1, and searches forward from each for the next
+// table matching l's selector, looking inside wrappers too, so an ad or div
+// in between doesn't hide it. The first heading with a table wins. If a
+// later heading or the table's caption names another year instead, the
+// table isn't year's and wrongYear says which year it was for.
+func findYearTable(doc *goquery.Document, year int, l layout) (yearTable, error) {
+	re, err := l.yearHeaderRegexp(year)
+	if err != nil {
+		return yearTable{}, err
+	}
+	want := strconv.Itoa(year)
+
+	var first yearTable
+	for _, level := range headings {
+		var found yearTable
+		doc.Find(level).EachWithBreak(func(_ int, h *goquery.Selection) bool {
+			if !re.MatchString(headingText(h)) {
+				return true
+			}
+			t := yearTable{header: h, level: level}
+			t.table, t.wrongYear = tableAfter(h, l.selector, want)
+			if first.header == nil {
+				first = t
+			}
+			if t.table != nil {
+				found = t
+			}
+			return found.table == nil
+		})
+		if found.table != nil {
+			return found, nil
+		}
+	}
+	return first, nil
+}
+
+// tableAfter searches forward from heading h for year want's table; see
+// findYearTable
+func tableAfter(h *goquery.Selection, selector, want string) (table *goquery.Selection, wrongYear string) {
+	match := cmp.Or(selector, "table")
+	between := ""
+	h.NextAll().EachWithBreak(func(_ int, sib *goquery.Selection) bool {
+		if sib.Is("h1, h2, h3") {
+			between = headingText(sib)
+			return true
+		}
+		if sib.Is("table") && sib.Is(match) {
+			table = sib
+		} else if t := sib.Find(match).First(); t.Length() > 0 && t.Is("table") {
+			table = t
+		}
+		return table == nil
+	})
+	if table == nil {
+		return nil, ""
+	}
+	for _, text := range []string{asciiDigits.Replace(table.Find("caption").First().Text()), between} {
+		if other := otherYear(text, want); other != "" {
+			return nil, other
+		}
+	}
+	return table, ""
+}
+
+// otherYear is the first year text mentions, if it mentions any but want
+func otherYear(text, want string) string {
+	years := yearPattern.FindAllString(text, -1)
+	if len(years) == 0 || slices.Contains(years, want) {
+		return ""
+	}
+	return years[0]
+}
+
+// publishedYears lists the years a heading on the page names that are
+// followed by a holiday table, in page order
+func publishedYears(html string, l layout) ([]int, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, err
+	}
+	var years []int
+	var errs []error
+	doc.Find("h1, h2, h3").Each(func(_ int, h *goquery.Selection) {
+		for _, y := range yearPattern.FindAllString(headingText(h), -1) {
+			year, _ := strconv.Atoi(y)
+			if slices.Contains(years, year) {
+				continue
+			}
+			found, err := findYearTable(doc, year, l)
+			if err != nil {
+				errs = append(errs, err)
+			} else if found.table != nil {
+				years = append(years, year)
+			}
+		}
+	})
+	return years, errors.Join(errs...)
+}
+
+// PageCheck is what CheckPage found on a state page
+type PageCheck struct {
+	URL string
+	// YearHeader is set if an h1-h3 heading names the year
+	YearHeader bool
+	// Table is set if a table matching the selector follows that header.
+	// WrongYear is the year the next table is for when it isn't this one.
+	Table     bool
+	WrongYear string
+	// Rows counts the table's body rows; ShortRows those with fewer than
+	// the three cells (date, day, name) ParseRows needs
+	Rows, ShortRows int
+}
+
+// OK reports whether the page has the structure the scraper relies on
+func (c PageCheck) OK() bool {
+	return c.YearHeader && c.Table && c.Rows > c.ShortRows
+}
+
+// Problems describes each way c falls short, for logging
+func (c PageCheck) Problems() []string {
+	var out []string
+	switch {
+	case !c.YearHeader:
+		out = append(out, "no h1-h3 heading naming the year")
+	case c.WrongYear != "":
+		out = append(out, "the table after the year header is for "+c.WrongYear)
+	case !c.Table:
+		out = append(out, "no holiday table after the year header")
+	case c.Rows == 0:
+		out = append(out, "the holiday table has no rows")
+	case c.Rows == c.ShortRows:
+		out = append(out, "no row has the date, day and name columns")
+	}
+	return out
+}
+
+// checkHTML inspects html the way extractRows reads it
+func checkHTML(html string, year int, l layout) (PageCheck, error) {
+	var c PageCheck
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return c, err
+	}
+	found, err := findYearTable(doc, year, l)
+	if err != nil {
+		return c, err
+	}
+	c.YearHeader, c.Table, c.WrongYear = found.header != nil, found.table != nil, found.wrongYear
+	if found.table == nil {
+		return c, nil
+	}
+	found.table.Find("tbody tr").Each(func(_ int, tr *goquery.Selection) {
+		c.Rows++
+		if tr.Find("td").Length() < 3 {
+			c.ShortRows++
+		}
+	})
+	return c, nil
+}
@@ -0,0 +1,419 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// newFixtureServer serves testdata/<state>-<year>.html at the same
+// /<state>/<year>-dates/ paths as the real site
+func newFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, rest, ok := strings.Cut(strings.Trim(r.URL.Path, "/"), "/")
+		year, found := strings.CutSuffix(rest, "-dates")
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", state+"-"+year+".html"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// fetchFixture loads state's page from srv and runs it through the same
+// extraction and parsing fetchState uses
+func fetchFixture(t *testing.T, srv *httptest.Server, state string, year int) []Holiday {
+	t.Helper()
+	resp, err := http.Get(PageURL(srv.URL, state, year))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s", resp.Request.URL, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, found, err := extractRows(string(body), year, defaultLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.wrongYear != "" {
+		t.Fatalf("%s: table is for %s, not %d", state, found.wrongYear, year)
+	}
+	holidays, err := ParseRows(rows, state, year)
+	if err != nil {
+		t.Fatalf("ParseRows(%s): %v", state, err)
+	}
+	return holidays
+}
+
+func TestFixtureStates(t *testing.T) {
+	srv := newFixtureServer(t)
+	tests := []struct {
+		state string
+		count int
+		first Holiday
+		last  Holiday
+	}{
+		{
+			state: "selangor",
+			count: 19,
+			first: Holiday{Date: "2025-01-01", Day: "Wednesday", Name: "New Year's Day"},
+			last:  Holiday{Date: "2025-12-25", Day: "Thursday", Name: "Christmas Day"},
+		},
+		{
+			state: "kuala-lumpur",
+			count: 19,
+			first: Holiday{Date: "2025-01-01", Day: "Wednesday", Name: "New Year's Day"},
+			last:  Holiday{Date: "2025-12-25", Day: "Thursday", Name: "Christmas Day"},
+		},
+	}
+	for _, tt := range tests {
+		got := fetchFixture(t, srv, tt.state, 2025)
+		if len(got) != tt.count {
+			t.Errorf("%s: got %d holidays, want %d", tt.state, len(got), tt.count)
+			continue
+		}
+		for _, c := range []struct{ got, want Holiday }{{got[0], tt.first}, {got[len(got)-1], tt.last}} {
+			if c.got.Date != c.want.Date || c.got.Day != c.want.Day || c.got.Name != c.want.Name {
+				t.Errorf("%s: got %s %s %q, want %s %s %q", tt.state,
+					c.got.Date, c.got.Day, c.got.Name, c.want.Date, c.want.Day, c.want.Name)
+			}
+			if !reflect.DeepEqual(c.got.States, []string{tt.state}) {
+				t.Errorf("%s: States = %v", tt.state, c.got.States)
+			}
+		}
+	}
+
+	// the two pages share most holidays, which consolidate into one entry
+	merged := Consolidate(append(fetchFixture(t, srv, "selangor", 2025), fetchFixture(t, srv, "kuala-lumpur", 2025)...))
+	if len(merged) != 20 {
+		t.Errorf("consolidated %d holidays, want 20", len(merged))
+	}
+}
+
+// TestFixtureAllStates pins every state's 2025 fixture to its holiday count
+// and a few holidays it must (or, for nationwide ones some states skip,
+// mustn't) have, so a parsing change that drops or invents rows fails here.
+// Update the expectations when a fixture is refreshed from the site.
+func TestFixtureAllStates(t *testing.T) {
+	srv := newFixtureServer(t)
+	type day struct{ Date, Name string }
+	tests := []struct {
+		state   string
+		count   int
+		has     []day
+		missing []string
+	}{
+		{"johor", 19, []day{{"2025-03-23", "Sultan of Johor's Birthday"}, {"2025-03-02", "Awal Ramadan"}}, []string{"New Year's Day"}},
+		{"kedah", 19, []day{{"2025-06-15", "Sultan of Kedah's Birthday"}, {"2025-01-27", "Israk and Mikraj"}}, []string{"New Year's Day", "National Day Holiday"}},
+		{"kelantan", 18, []day{{"2025-06-06", "Arafat Day"}, {"2025-09-29", "Sultan of Kelantan's Birthday"}}, []string{"Chinese New Year Holiday", "Thaipusam"}},
+		{"kuala-lumpur", 19, []day{{"2025-02-01", "Federal Territory Day"}, {"2025-02-11", "Thaipusam"}}, nil},
+		{"labuan", 20, []day{{"2025-02-01", "Federal Territory Day"}, {"2025-05-30", "Pesta Kaamatan"}}, nil},
+		{"melaka", 19, []day{{"2025-04-15", "Declaration of Malacca as a Historical City"}, {"2025-08-25", "Governor of Melaka's Birthday"}}, []string{"Thaipusam"}},
+		{"negeri-sembilan", 19, []day{{"2025-01-14", "Birthday of Yang di-Pertuan Besar of Negeri Sembilan"}}, []string{"Nuzul Al-Quran"}},
+		{"pahang", 19, []day{{"2025-05-22", "Hari Hol Almarhum Sultan Abu Bakar"}, {"2025-07-30", "Sultan of Pahang's Birthday"}}, nil},
+		{"penang", 20, []day{{"2025-07-07", "George Town World Heritage City Day"}, {"2025-07-12", "Governor of Penang's Birthday"}}, nil},
+		{"perak", 19, []day{{"2025-11-07", "Sultan of Perak's Birthday"}}, nil},
+		{"perlis", 18, []day{{"2025-05-17", "Raja of Perlis' Birthday"}, {"2025-06-08", "Hari Raya Haji Holiday"}}, []string{"New Year's Day"}},
+		{"putrajaya", 19, []day{{"2025-02-01", "Federal Territory Day"}}, nil},
+		{"sabah", 21, []day{{"2025-04-18", "Good Friday"}, {"2025-10-04", "Governor of Sabah's Birthday"}, {"2025-12-24", "Christmas Eve"}}, []string{"Thaipusam"}},
+		{"sarawak", 20, []day{{"2025-06-01", "Gawai Dayak"}, {"2025-07-22", "Sarawak Day"}, {"2025-06-02", "Agong's Birthday"}}, []string{"Deepavali"}},
+		{"selangor", 19, []day{{"2025-12-11", "Sultan of Selangor's Birthday"}}, nil},
+		{"terengganu", 18, []day{{"2025-03-04", "Anniversary of the Installation of the Sultan of Terengganu"}, {"2025-04-26", "Sultan of Terengganu's Birthday"}}, []string{"Chinese New Year Holiday"}},
+	}
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		covered[tt.state] = true
+		t.Run(tt.state, func(t *testing.T) {
+			got := fetchFixture(t, srv, tt.state, 2025)
+			if len(got) != tt.count {
+				t.Errorf("got %d holidays, want %d", len(got), tt.count)
+			}
+			byName := map[string]string{}
+			for _, h := range got {
+				byName[h.Name] = h.Date
+			}
+			for _, want := range append(tt.has, day{"2025-05-01", "Labour Day"}, day{"2025-09-16", "Malaysia Day"}) {
+				if date, ok := byName[want.Name]; !ok || date != want.Date {
+					t.Errorf("%q on %q, want %s", want.Name, date, want.Date)
+				}
+			}
+			for _, name := range tt.missing {
+				if date, ok := byName[name]; ok {
+					t.Errorf("unexpected %q on %s", name, date)
+				}
+			}
+		})
+	}
+	for _, st := range AllStates() {
+		if !covered[st] {
+			t.Errorf("no fixture expectations for %s", st)
+		}
+	}
+}
+
+func TestFixtureEmptyPage(t *testing.T) {
+	srv := newFixtureServer(t)
+	if got := fetchFixture(t, srv, "empty", 2025); len(got) != 0 {
+		t.Errorf("empty page gave %d holidays: %+v", len(got), got)
+	}
+}
+
+func TestFixtureRanges(t *testing.T) {
+	srv := newFixtureServer(t)
+	got := fetchFixture(t, srv, "ranges", 2025)
+
+	var dates []string
+	for _, h := range got {
+		dates = append(dates, h.Date+" "+h.Day)
+	}
+	want := []string{
+		"2025-01-29 Wednesday", "2025-01-30 Thursday",
+		"2025-03-31 Monday", "2025-04-01 Tuesday",
+		"2025-06-06 Friday", "2025-06-07 Saturday", "2025-06-08 Sunday", "2025-06-09 Monday",
+		"2025-09-29 Monday",
+	}
+	if !reflect.DeepEqual(dates, want) {
+		t.Errorf("range rows expanded to\n%v\nwant\n%v", dates, want)
+	}
+}
+
+func TestCheckHTML(t *testing.T) {
+	tests := []struct {
+		file string
+		ok   bool
+	}{
+		{"selangor-2025.html", true},
+		{"ranges-2025.html", true},
+		{"empty-2025.html", false},
+		{"interstitial-2025.html", true},
+		{"stale-2025.html", false},
+	}
+	for _, tt := range tests {
+		html, err := os.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := checkHTML(string(html), 2025, defaultLayout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.OK() != tt.ok {
+			t.Errorf("%s: OK() = %v, want %v (%+v, %v)", tt.file, c.OK(), tt.ok, c, c.Problems())
+		}
+	}
+
+	// a renamed table class is exactly what the check should catch
+	html, _ := os.ReadFile(filepath.Join("testdata", "selangor-2025.html"))
+	renamed := strings.ReplaceAll(string(html), "publicholidays", "holiday-list")
+	if c, _ := checkHTML(renamed, 2025, defaultLayout); c.OK() || !c.YearHeader || c.Table {
+		t.Errorf("renamed table: %+v, want header found but no table", c)
+	}
+}
+
+func TestFixtureObservances(t *testing.T) {
+	srv := newFixtureServer(t)
+	got := fetchFixture(t, srv, "observances", 2025)
+
+	types := map[string]string{}
+	for _, h := range got {
+		types[h.Name] = h.Type
+	}
+	want := map[string]string{
+		"New Year's Day":                      "",
+		"Valentine's Day":                     TypeObservance,
+		"Labour Day":                          "",
+		"Mother's Day":                        TypeObservance,
+		"George Town World Heritage City Day": TypePublic,
+		"Halloween":                           TypeObservance,
+	}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("types = %v, want %v", types, want)
+	}
+
+	var kept []string
+	for _, h := range FilterGazetted(got) {
+		kept = append(kept, h.Name)
+	}
+	if want := []string{"New Year's Day", "Labour Day", "George Town World Heritage City Day"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("FilterGazetted kept %v, want %v", kept, want)
+	}
+}
+
+func TestParseHolidays(t *testing.T) {
+	html, err := os.ReadFile(filepath.Join("testdata", "selangor-2025.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseHolidays(string(html), "selangor", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 19 || got[0].Name != "New Year's Day" {
+		t.Errorf("got %d holidays starting with %+v, want 19 starting with New Year's Day", len(got), got[0])
+	}
+
+	empty, err := os.ReadFile(filepath.Join("testdata", "empty-2025.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseHolidays(string(empty), "empty", 2025); !errors.Is(err, ErrNoRows) {
+		t.Errorf("empty page: err = %v, want ErrNoRows", err)
+	}
+	// a malformed document is a parse problem, not a failed page load
+	if _, err := ParseHolidays("<table><tr><td>2025", "selangor", 2025); err == nil || errors.Is(err, ErrNavigation) {
+		t.Errorf("malformed page: err = %v, want an error that isn't ErrNavigation", err)
+	}
+}
+
+func TestFixtureTableSearch(t *testing.T) {
+	srv := newFixtureServer(t)
+
+	// an ad and a wrapper between the header and the table don't hide it
+	got := fetchFixture(t, srv, "interstitial", 2025)
+	if len(got) != 3 || got[2].Name != "Birthday of the Governor of Melaka" {
+		t.Errorf("interstitial page gave %d holidays: %+v", len(got), got)
+	}
+
+	// last year's table under its own header isn't this year's
+	html, err := os.ReadFile(filepath.Join("testdata", "stale-2025.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseHolidays(string(html), "sarawak", 2025); !errors.Is(err, ErrNoRows) || !strings.Contains(err.Error(), "2024") {
+		t.Errorf("stale page: err = %v, want ErrNoRows naming 2024", err)
+	}
+	if c, _ := checkHTML(string(html), 2025, defaultLayout); c.WrongYear != "2024" {
+		t.Errorf("stale page: WrongYear = %q, want 2024", c.WrongYear)
+	}
+}
+
+func TestParseHTMLRawRows(t *testing.T) {
+	html, err := os.ReadFile(filepath.Join("testdata", "ranges-2025.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw [][]string
+	if _, err := parseHTML(string(html), "kelantan", 2025, defaultLayout, func(rows [][]string) { raw = rows }); err != nil {
+		t.Fatal(err)
+	}
+	// the cells arrive as the page has them, before ranges are expanded
+	if len(raw) != 4 || !reflect.DeepEqual(raw[0], []string{"29 - 30 Jan", "Wed - Thu", "Chinese New Year"}) {
+		t.Errorf("raw rows = %q", raw)
+	}
+}
+
+func TestPublishedYears(t *testing.T) {
+	tests := []struct {
+		file string
+		want []int
+	}{
+		{"selangor-2025.html", []int{2025, 2026}},
+		{"stale-2025.html", []int{2024}},
+		{"empty-2025.html", nil},
+	}
+	for _, tt := range tests {
+		html, err := os.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := publishedYears(string(html), defaultLayout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: publishedYears = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestExtractRowsSuperscript(t *testing.T) {
+	page := `<h2>Penang Public Holidays 2025</h2>
+<table class="publicholidays"><tbody>
+<tr><td>20 Oct</td><td>Monday</td><td>Deepavali<sup>1</sup></td></tr>
+<tr><td colspan="3"><sup>1</sup> Subject to change</td></tr>
+</tbody></table>`
+	rows, _, err := extractRows(page, 2025, defaultLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holidays, err := ParseRows(rows, "penang", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(holidays) != 1 || holidays[0].Name != "Deepavali" || holidays[0].Note != "Subject to change" {
+		t.Errorf("got %+v, want Deepavali with its footnote", holidays)
+	}
+}
+
+func TestFindYearTableHeadings(t *testing.T) {
+	table := `<table class="publicholidays"><tbody><tr><td>1 Jan</td><td>Wednesday</td><td>New Year's Day</td></tr></tbody></table>`
+	tests := []struct {
+		name    string
+		page    string
+		pattern string
+		level   string
+	}{
+		{"h2", `<h2>Holidays 2025</h2>` + table, "", "h2"},
+		{"h3 only", `<h1>Penang</h1><h3>Holidays 2025</h3>` + table, "", "h3"},
+		{"h2 without table", `<section><h2>Holidays 2025</h2><p>Soon</p></section><h3>2025 list</h3>` + table, "", "h3"},
+		{"full-width digits", `<h2>Holidays ２０２５</h2>` + table, "", "h2"},
+		{"custom pattern", `<h2>Cuti Umum Tahun 2025</h2>` + table, `(?i)tahun {year}`, "h2"},
+		{"custom pattern rejects", `<h2>Holidays 2025</h2>` + table, `(?i)tahun {year}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			found, err := findYearTable(doc, 2025, layout{selector: DefaultTableSelector, yearHeader: tt.pattern})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found.level != tt.level || (found.table != nil) != (tt.level != "") {
+				t.Errorf("matched %q (table %v), want %q", found.level, found.table != nil, tt.level)
+			}
+		})
+	}
+}
+
+func TestCheckYearHeader(t *testing.T) {
+	for pattern, ok := range map[string]bool{
+		DefaultYearHeader: true,
+		`Tahun {year}`:    true,
+		`Holidays 2025`:   false,
+		`({year}`:         false,
+	} {
+		if err := CheckYearHeader(pattern); (err == nil) != ok {
+			t.Errorf("CheckYearHeader(%q) = %v", pattern, err)
+		}
+	}
+}
+
+func TestParseHTMLBadYearHeader(t *testing.T) {
+	html, err := os.ReadFile("testdata/selangor-2025.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseHTML(string(html), "selangor", 2025, layout{selector: DefaultTableSelector, yearHeader: `({year}`}, nil)
+	if !errors.Is(err, ErrParse) || errors.Is(err, ErrNavigation) || retryable(err) {
+		t.Errorf("bad year header: err = %v, want ErrParse that isn't retried", err)
+	}
+}
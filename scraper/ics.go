@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// SaveICS writes holidays as an iCalendar file; see WriteICS
+func SaveICS(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteICS)
+}
+
+// WriteICS writes holidays to out as an iCalendar feed with one all-day
+// VEVENT per holiday. UIDs are derived from Holiday.ID so re-importing the
+// file updates existing events instead of duplicating them, even after a
+// respelling or with names localized.
+func WriteICS(out io.Writer, holidays []Holiday) error {
+	w := bufio.NewWriter(out)
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//cuti-cli//Malaysia Public Holidays//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	}
+	for _, h := range holidays {
+		start, err := h.AsTime()
+		if err != nil {
+			return fmt.Errorf("holiday %q: %w", h.Name, err)
+		}
+		// DTEND is exclusive for all-day events
+		end := start.AddDate(0, 0, 1)
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+icsUID(h),
+			"DTSTAMP:"+stamp,
+			"DTSTART;VALUE=DATE:"+start.Format("20060102"),
+			"DTEND;VALUE=DATE:"+end.Format("20060102"),
+			"SUMMARY:"+icsEscape(h.Name),
+			"DESCRIPTION:"+icsEscape("States: "+strings.Join(h.States, ", ")),
+			"X-CUTI-STATES:"+icsEscape(strings.Join(h.States, ",")),
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, l := range lines {
+		if _, err := w.WriteString(icsFold(l)); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// icsUID is h's ID as a UID, the same identity the SQLite rows and Google
+// Calendar events use
+func icsUID(h Holiday) string {
+	return h.ID() + "@cuti-cli"
+}
+
+// icsEscape escapes TEXT values per RFC 5545 §3.3.11
+func icsEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// icsFold terminates a content line with CRLF, folding it so no physical
+// line exceeds 75 octets (RFC 5545 §3.1).
+func icsFold(line string) string {
+	const limit = 75
+	var b strings.Builder
+	n := 0
+	for _, r := range line {
+		size := len(string(r))
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteICSUIDs(t *testing.T) {
+	holidays := Consolidate([]Holiday{
+		{Date: "2025-06-07", Day: "Saturday", Name: "Hari Raya Haji", States: []string{"selangor"}},
+	})
+	uids := func(holidays []Holiday) []string {
+		var buf bytes.Buffer
+		if err := WriteICS(&buf, holidays); err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, l := range strings.Split(buf.String(), "\r\n") {
+			if uid, ok := strings.CutPrefix(l, "UID:"); ok {
+				out = append(out, uid)
+			}
+		}
+		return out
+	}
+
+	want := holidays[0].ID() + "@cuti-cli"
+	for _, tt := range []struct {
+		name     string
+		holidays []Holiday
+	}{
+		{"canonical", holidays},
+		{"localized", Localize(holidays, LangMalay)},
+	} {
+		if got := uids(tt.holidays); len(got) != 1 || got[0] != want {
+			t.Errorf("%s: UIDs = %v, want [%s]", tt.name, got, want)
+		}
+	}
+	if Localize(holidays, LangMalay)[0].Name == holidays[0].Name {
+		t.Error("Localize didn't rename the holiday, so the localized case proves nothing")
+	}
+}
@@ -0,0 +1,96 @@
+package scraper
+
+import "time"
+
+// LongWeekend is a run of consecutive non-working days (holidays plus
+// Saturdays and Sundays) of three days or more. When Bridge is set the run
+// only exists if that working day is taken as leave.
+type LongWeekend struct {
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Days     int      `json:"days"`
+	Holidays []string `json:"holidays"`
+	Bridge   string   `json:"bridge,omitempty"`
+}
+
+// offRun is a maximal stretch of consecutive non-working days
+type offRun struct {
+	start, end time.Time
+	holiday    bool
+}
+
+// AnalyzeLongWeekends finds long weekends and single-day bridges in
+// holidays. Weekends are taken to be Saturday and Sunday, so filter the
+// input to one state first for a per-state view; states with a Friday and
+// Saturday weekend are not special-cased. Holidays with unparseable dates
+// are ignored.
+func AnalyzeLongWeekends(holidays []Holiday) []LongWeekend {
+	names := map[time.Time][]string{}
+	var first, last time.Time
+	for _, h := range holidays {
+		t, err := h.AsTime()
+		if err != nil {
+			continue
+		}
+		names[t] = append(names[t], h.Name)
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	isOff := func(t time.Time) bool {
+		wd := t.Weekday()
+		return wd == time.Saturday || wd == time.Sunday || names[t] != nil
+	}
+
+	// Pad by a week each side so runs touching the first/last holiday are
+	// complete and bridges to the neighbouring weekend are found.
+	var runs []offRun
+	for d := first.AddDate(0, 0, -7); !d.After(last.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+		if !isOff(d) {
+			continue
+		}
+		if n := len(runs); n > 0 && runs[n-1].end.AddDate(0, 0, 1).Equal(d) {
+			runs[n-1].end = d
+			runs[n-1].holiday = runs[n-1].holiday || names[d] != nil
+			continue
+		}
+		runs = append(runs, offRun{start: d, end: d, holiday: names[d] != nil})
+	}
+
+	span := func(start, end time.Time) LongWeekend {
+		lw := LongWeekend{
+			Start: start.Format(DateLayout),
+			End:   end.Format(DateLayout),
+			Days:  int(end.Sub(start).Hours()/24) + 1,
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			lw.Holidays = append(lw.Holidays, names[d]...)
+		}
+		return lw
+	}
+
+	var out []LongWeekend
+	for i, r := range runs {
+		if r.holiday && !r.end.Before(r.start.AddDate(0, 0, 2)) {
+			out = append(out, span(r.start, r.end))
+		}
+
+		// One working day between this run and the next
+		if i+1 < len(runs) {
+			next := runs[i+1]
+			if (r.holiday || next.holiday) && next.start.Equal(r.end.AddDate(0, 0, 2)) {
+				lw := span(r.start, next.end)
+				lw.Bridge = r.end.AddDate(0, 0, 1).Format(DateLayout)
+				out = append(out, lw)
+			}
+		}
+	}
+	return out
+}
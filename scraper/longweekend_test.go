@@ -0,0 +1,23 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeLongWeekends(t *testing.T) {
+	got := AnalyzeLongWeekends([]Holiday{
+		// Friday: Fri-Sun long weekend
+		{Date: "2025-05-02", Name: "Holiday A"},
+		// Thursday: Friday bridges into the weekend
+		{Date: "2025-05-22", Name: "Holiday B"},
+	})
+
+	want := []LongWeekend{
+		{Start: "2025-05-02", End: "2025-05-04", Days: 3, Holidays: []string{"Holiday A"}},
+		{Start: "2025-05-22", End: "2025-05-25", Days: 4, Holidays: []string{"Holiday B"}, Bridge: "2025-05-23"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeLongWeekends() =\n%+v\nwant\n%+v", got, want)
+	}
+}
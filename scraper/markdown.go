@@ -0,0 +1,32 @@
+package scraper
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Save to Markdown
+func SaveMarkdown(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteMarkdown)
+}
+
+// WriteMarkdown writes holidays to out as a GitHub-flavored Markdown table
+// with states joined by ", ".
+func WriteMarkdown(out io.Writer, holidays []Holiday) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintln(w, "| Date | Day | Name | States |")
+	fmt.Fprintln(w, "|------|-----|------|--------|")
+	for _, h := range holidays {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n",
+			mdEscape(h.Date), mdEscape(h.Day), mdEscape(h.Name), mdEscape(strings.Join(h.States, ", ")))
+	}
+	return w.Flush()
+}
+
+// mdEscape keeps a cell from breaking out of its table column
+func mdEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
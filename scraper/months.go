@@ -0,0 +1,46 @@
+package scraper
+
+import (
+	"sort"
+	"time"
+)
+
+// MonthGroup is one month's holidays, as returned by GroupByMonth
+type MonthGroup struct {
+	// Month is the year and month, e.g. "2025-01"
+	Month    string    `json:"month"`
+	Holidays []Holiday `json:"holidays"`
+}
+
+// Label is the month spelled out for headers, e.g. "January 2025", or
+// Month itself if it doesn't parse
+func (g MonthGroup) Label() string {
+	t, err := time.Parse("2006-01", g.Month)
+	if err != nil {
+		return g.Month
+	}
+	return t.Format("January 2006")
+}
+
+// GroupByMonth buckets holidays by the month they fall in, months in
+// order and holidays in date order within each. Months without holidays
+// are left out, as are holidays with unparseable dates.
+func GroupByMonth(holidays []Holiday) []MonthGroup {
+	byMonth := map[string][]Holiday{}
+	for _, h := range holidays {
+		t, err := h.AsTime()
+		if err != nil {
+			continue
+		}
+		m := t.Format("2006-01")
+		byMonth[m] = append(byMonth[m], h)
+	}
+
+	groups := make([]MonthGroup, 0, len(byMonth))
+	for m, hs := range byMonth {
+		sort.SliceStable(hs, func(i, j int) bool { return dateLess(hs[i], hs[j]) })
+		groups = append(groups, MonthGroup{Month: m, Holidays: hs})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Month < groups[j].Month })
+	return groups
+}
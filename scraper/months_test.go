@@ -0,0 +1,37 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupByMonth(t *testing.T) {
+	got := GroupByMonth([]Holiday{
+		{Date: "2025-03-31", Name: "Hari Raya Aidilfitri"},
+		{Date: "2025-01-29", Name: "Chinese New Year"},
+		{Date: "2024-12-25", Name: "Christmas Day"},
+		{Date: "2025-01-01", Name: "New Year's Day"},
+		{Date: "not a date", Name: "Broken"},
+	})
+
+	var months []string
+	var names [][]string
+	for _, g := range got {
+		months = append(months, g.Month)
+		var n []string
+		for _, h := range g.Holidays {
+			n = append(n, h.Name)
+		}
+		names = append(names, n)
+	}
+	if want := []string{"2024-12", "2025-01", "2025-03"}; !reflect.DeepEqual(months, want) {
+		t.Errorf("months = %v, want %v", months, want)
+	}
+	want := [][]string{{"Christmas Day"}, {"New Year's Day", "Chinese New Year"}, {"Hari Raya Aidilfitri"}}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("holidays = %v, want %v", names, want)
+	}
+	if l := got[1].Label(); l != "January 2025" {
+		t.Errorf("Label() = %q, want January 2025", l)
+	}
+}
@@ -0,0 +1,187 @@
+package scraper
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed names.yaml
+var defaultNames []byte
+
+//go:embed names_ms.yaml
+var malayNamesYAML []byte
+
+// malayNames maps canonical names to Bahasa Malaysia
+var malayNames = func() map[string]string {
+	var m map[string]string
+	if err := yaml.Unmarshal(malayNamesYAML, &m); err != nil {
+		panic(fmt.Sprintf("scraper: bad embedded names_ms.yaml: %v", err))
+	}
+	return m
+}()
+
+// Languages Localize accepts
+const (
+	LangEnglish = "en"
+	LangMalay   = "ms"
+)
+
+var (
+	namesMu sync.RWMutex
+	// canonicalNames maps aliasKey(alias) to its canonical name
+	canonicalNames = mustParseNames(defaultNames)
+
+	// trailingParen splits "Hari Raya Puasa (Day 2)" into name and suffix
+	trailingParen = regexp.MustCompile(`^(.*?)(\s*\([^)]*\))$`)
+)
+
+var (
+	// footnoteMarker is a footnote marker trailing a holiday name: asterisks,
+	// daggers, superscript digits (extractRows turns <sup> into these) or a
+	// bracketed number like [1]
+	footnoteMarker = regexp.MustCompile(`\s*(\*+|[†‡§]+|[⁰¹²³⁴⁵⁶⁷⁸⁹]+|\[\d+\])$`)
+	// footnoteText is a footnote row: a marker followed by its text
+	footnoteText = regexp.MustCompile(`^(\*+|[†‡§]+|[⁰¹²³⁴⁵⁶⁷⁸⁹]+|\[\d+\])\s*(.+)$`)
+)
+
+// stripFootnote splits a trailing footnote marker such as the * in
+// "Deepavali*" off name, returning "" for marker when there is none
+func stripFootnote(name string) (clean, marker string) {
+	m := footnoteMarker.FindStringSubmatchIndex(name)
+	if m == nil {
+		return name, ""
+	}
+	return strings.TrimSpace(name[:m[0]]), name[m[2]:m[3]]
+}
+
+// footnotes collects the footnote rows of a table, the short rows starting
+// with a marker, as marker → text
+func footnotes(rows [][]string) map[string]string {
+	notes := map[string]string{}
+	for _, r := range rows {
+		if len(r) == 0 || len(r) >= 3 {
+			continue
+		}
+		if m := footnoteText.FindStringSubmatch(strings.Join(r, " ")); m != nil {
+			notes[m[1]] = m[2]
+		}
+	}
+	return notes
+}
+
+// superscripts maps digits to their superscript forms
+var superscripts = strings.NewReplacer(
+	"0", "⁰", "1", "¹", "2", "²", "3", "³", "4", "⁴",
+	"5", "⁵", "6", "⁶", "7", "⁷", "8", "⁸", "9", "⁹",
+)
+
+// CanonicalizeName maps a known alias such as "Nuzul Quran" to its
+// canonical name, "Nuzul Al-Quran", keeping any trailing parenthetical like
+// "(Day 2)". Unknown names are returned unchanged. The built-in table can be
+// extended with LoadNameAliases.
+func CanonicalizeName(name string) string {
+	base, suffix := name, ""
+	if m := trailingParen.FindStringSubmatch(name); m != nil {
+		base, suffix = m[1], m[2]
+	}
+
+	namesMu.RLock()
+	canonical, ok := canonicalNames[aliasKey(base)]
+	namesMu.RUnlock()
+	if !ok {
+		return name
+	}
+	return canonical + suffix
+}
+
+// LoadNameAliases adds the YAML map of canonical name to aliases at path,
+// in the same shape as the built-in names.yaml, on top of the defaults. An
+// alias listed here wins over a built-in one.
+func LoadNameAliases(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	extra, err := parseNames(data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	namesMu.Lock()
+	defer namesMu.Unlock()
+	for k, v := range extra {
+		canonicalNames[k] = v
+	}
+	return nil
+}
+
+// parseNames turns a canonical → aliases YAML map into an alias lookup.
+// Each canonical name is also an alias of itself, so differently spaced or
+// cased copies of it converge too.
+func parseNames(data []byte) (map[string]string, error) {
+	var raw map[string][]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	out := make(map[string]string)
+	for canonical, aliases := range raw {
+		out[aliasKey(canonical)] = canonical
+		for _, alias := range aliases {
+			out[aliasKey(alias)] = canonical
+		}
+	}
+	return out, nil
+}
+
+func mustParseNames(data []byte) map[string]string {
+	names, err := parseNames(data)
+	if err != nil {
+		panic(fmt.Sprintf("scraper: bad embedded names.yaml: %v", err))
+	}
+	return names
+}
+
+// aliasKey is how aliases are matched: lowercased, apostrophes dropped,
+// whitespace collapsed
+func aliasKey(name string) string {
+	k := strings.ToLower(name)
+	k = strings.NewReplacer("'", "", "’", "").Replace(k)
+	return strings.TrimSpace(whitespace.ReplaceAllString(k, " "))
+}
+
+// translateName returns the English and Bahasa Malaysia forms of a
+// canonical name, keeping a trailing parenthetical and turning "(Day 2)"
+// into "(Hari 2)". ms is empty when there is no translation.
+func translateName(name string) (en, ms string) {
+	base, suffix := name, ""
+	if m := trailingParen.FindStringSubmatch(name); m != nil {
+		base, suffix = m[1], m[2]
+	}
+	if t, ok := malayNames[base]; ok {
+		ms = t + strings.Replace(suffix, "(Day ", "(Hari ", 1)
+	}
+	return name, ms
+}
+
+// Localize sets each holiday's Name to its lang form, LangEnglish or
+// LangMalay, falling back to English where no translation is known.
+// NameEn and NameMs are left as they were.
+func Localize(holidays []Holiday, lang string) []Holiday {
+	out := make([]Holiday, len(holidays))
+	for i, h := range holidays {
+		switch {
+		case lang == LangMalay && h.NameMs != "":
+			h.Name = h.NameMs
+		case h.NameEn != "":
+			h.Name = h.NameEn
+		}
+		out[i] = h
+	}
+	return out
+}
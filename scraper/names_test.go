@@ -0,0 +1,90 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalizeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Nuzul Quran", "Nuzul Al-Quran"},
+		{"nuzul al-qur'an", "Nuzul Al-Quran"},
+		{"Hari Raya Puasa (Day 2)", "Hari Raya Aidilfitri (Day 2)"},
+		{"Hari Raya Aidil  Fitri", "Hari Raya Aidilfitri"},
+		{"Maulidur Rasul", "Prophet Muhammad's Birthday"},
+		{"Diwali", "Deepavali"},
+		{"Vesak Day", "Wesak Day"},
+		{"Merdeka Day", "National Day"},
+		// different festivals that must stay apart
+		{"Thaipusam", "Thaipusam"},
+		{"Thai Pongal", "Thai Pongal"},
+		{"Sultan of Johor's Birthday", "Sultan of Johor's Birthday"},
+	}
+	for _, tt := range tests {
+		if got := CanonicalizeName(tt.in); got != tt.want {
+			t.Errorf("CanonicalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalizeNameMergesInConsolidate(t *testing.T) {
+	a, err := ParseRows([][]string{{"18 Mar", "Tuesday", "Nuzul Quran"}}, "selangor", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParseRows([][]string{{"18 Mar", "Tuesday", "Nuzul Al-Qur'an"}}, "kuala-lumpur", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Consolidate(append(a, b...))
+	if len(got) != 1 || got[0].Name != "Nuzul Al-Quran" || len(got[0].States) != 2 {
+		t.Errorf("Consolidate = %+v, want one Nuzul Al-Quran for both states", got)
+	}
+}
+
+func TestLoadNameAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "names.yaml")
+	if err := os.WriteFile(path, []byte("Hari Wilayah: [Federal Territory Day]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadNameAliases(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { canonicalNames = mustParseNames(defaultNames) })
+
+	if got := CanonicalizeName("Federal Territory Day"); got != "Hari Wilayah" {
+		t.Errorf("CanonicalizeName after override = %q, want Hari Wilayah", got)
+	}
+	if got := CanonicalizeName("Diwali"); got != "Deepavali" {
+		t.Errorf("override dropped the defaults: Diwali = %q", got)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	got, err := ParseRows([][]string{
+		{"1 May", "Thursday", "Hari Pekerja"},
+		{"31 Mar", "Monday", "Hari Raya Puasa (Day 1)"},
+		{"22 Nov", "Saturday", "Sultan of Perak's Birthday"},
+	}, "perak", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].NameEn != "Labour Day" || got[0].NameMs != "Hari Pekerja" {
+		t.Errorf("names = %q / %q, want Labour Day / Hari Pekerja", got[0].NameEn, got[0].NameMs)
+	}
+
+	ms := Localize(got, LangMalay)
+	want := []string{"Hari Pekerja", "Hari Raya Aidilfitri (Hari 1)", "Sultan of Perak's Birthday"}
+	for i, h := range ms {
+		if h.Name != want[i] {
+			t.Errorf("Localize(ms)[%d] = %q, want %q", i, h.Name, want[i])
+		}
+	}
+	if en := Localize(ms, LangEnglish); en[0].Name != "Labour Day" {
+		t.Errorf("Localize(en) = %q, want Labour Day", en[0].Name)
+	}
+}
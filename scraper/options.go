@@ -0,0 +1,156 @@
+package scraper
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures a Scraper; pass any number to NewScraper
+type Option func(*Scraper)
+
+// WithHeadless runs Chrome without a visible window
+func WithHeadless(headless bool) Option {
+	return func(s *Scraper) { s.headless = headless }
+}
+
+// WithTimeout bounds each page load, covering navigation, waiting for the
+// table and extracting its rows. Defaults to 20s.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Scraper) { s.timeout = d }
+}
+
+// WithUserAgent overrides the browser's User-Agent on every page load.
+// Empty keeps Chrome's own, which advertises HeadlessChrome when headless.
+// Defaults to DefaultUserAgent.
+func WithUserAgent(ua string) Option {
+	return func(s *Scraper) { s.userAgent = ua }
+}
+
+// WithRetries sets how many extra attempts a failed page load gets, waiting
+// backoff before the first retry and doubling it each time after. Defaults
+// to 3 retries from 2s.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(s *Scraper) {
+		s.retries = n
+		s.retryBackoff = backoff
+	}
+}
+
+// WithDelay spaces out page loads from the network so they start at most
+// once every d across all of FetchAll's tabs; cached pages and snapshots
+// don't count. Zero disables the limit. Defaults to 1s.
+func WithDelay(d time.Duration) Option {
+	return func(s *Scraper) { s.delay = d }
+}
+
+// WithConcurrency sets how many tabs FetchAll scrapes with in parallel.
+// Defaults to 4.
+func WithConcurrency(n int) Option {
+	return func(s *Scraper) { s.concurrency = n }
+}
+
+// WithCacheDir keeps HTML snapshots of fetched pages, and the holidays
+// parsed from them, in dir. A state with cached holidays isn't fetched at
+// all; a page with a snapshot is loaded from disk instead of the network.
+// Anything missing is fetched live and then saved there.
+func WithCacheDir(dir string) Option {
+	return func(s *Scraper) { s.cacheDir = dir }
+}
+
+// WithChromePath runs the Chrome or Chromium binary at path, or found on
+// PATH under that name, instead of searching for one
+func WithChromePath(path string) Option {
+	return func(s *Scraper) { s.chromePath = path }
+}
+
+// WithProxy sends all browser traffic through proxy, e.g.
+// "http://proxy.corp:3128" or "socks5://127.0.0.1:1080". Chrome ignores
+// credentials embedded in the URL; a proxy that requires authentication
+// needs to be fronted by a local unauthenticated one.
+func WithProxy(proxy string) Option {
+	return func(s *Scraper) { s.proxy = proxy }
+}
+
+// WithStrict fails a page outright if any of its rows is invalid, instead
+// of skipping those rows.
+func WithStrict(strict bool) Option {
+	return func(s *Scraper) { s.strict = strict }
+}
+
+// WithRefresh ignores anything in the cache directory and fetches every
+// page live, overwriting what was cached.
+func WithRefresh(refresh bool) Option {
+	return func(s *Scraper) { s.refresh = refresh }
+}
+
+// WithMaxAge treats cache entries older than d as missing. Zero (the
+// default) never expires them.
+func WithMaxAge(d time.Duration) Option {
+	return func(s *Scraper) { s.maxAge = d }
+}
+
+// WithProgress calls fn as FetchAll starts and finishes each state. Calls
+// are never concurrent.
+func WithProgress(fn func(Progress)) Option {
+	return func(s *Scraper) { s.progress = fn }
+}
+
+// WithRawRows calls fn with the table cells read from each page before
+// ParseRows normalizes them, for debugging and building fixtures. States
+// served from the holiday cache aren't read, so fn isn't called for them.
+// Calls may come from several tabs at once.
+func WithRawRows(fn func(state string, year int, rows [][]string)) Option {
+	return func(s *Scraper) { s.rawRows = fn }
+}
+
+// WithFetchStats calls fn as each state's fetch finishes, for metrics.
+// Calls may come from several tabs at once.
+func WithFetchStats(fn func(FetchStats)) Option {
+	return func(s *Scraper) { s.fetchStats = fn }
+}
+
+// WithContext ties the browser to ctx. Once ctx is cancelled FetchAll stops
+// starting new states and returns what it has collected; pages in flight
+// are abandoned.
+func WithContext(ctx context.Context) Option {
+	return func(s *Scraper) { s.parent = ctx }
+}
+
+// WithBaseURL scrapes a mirror or local copy of the site instead of
+// DefaultBaseURL. It must serve the same /<state>/<year>-dates/ paths.
+func WithBaseURL(base string) Option {
+	return func(s *Scraper) { s.baseURL = base }
+}
+
+// WithTableSelector overrides the CSS selector FetchState waits for before
+// reading rows. If it never matches, any table following the year's header
+// is read instead. Empty keeps DefaultTableSelector.
+func WithTableSelector(selector string) Option {
+	return func(s *Scraper) {
+		if selector != "" {
+			s.tableSelector = selector
+		}
+	}
+}
+
+// WithYearHeaderRegex overrides the pattern h1-h3 headings are matched
+// against to find the year's table, with {year} standing for the year; see
+// CheckYearHeader. Empty keeps DefaultYearHeader.
+func WithYearHeaderRegex(pattern string) Option {
+	return func(s *Scraper) { s.yearHeader = pattern }
+}
+
+// WithBlockResources controls whether tabs refuse images, fonts and CSS to
+// load pages faster. Turn it off if the table stops rendering without
+// them. Defaults to true.
+func WithBlockResources(block bool) Option {
+	return func(s *Scraper) { s.blocking = block }
+}
+
+// WithMaxFailures makes FetchAll stop starting new states once n states
+// have failed, counted across every call on the Scraper, so an outage fails
+// fast. Its error then wraps ErrTooManyFailures. Zero, the default, never
+// stops early.
+func WithMaxFailures(n int) Option {
+	return func(s *Scraper) { s.maxFailures = n }
+}
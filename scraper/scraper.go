@@ -1,129 +1,924 @@
 package scraper
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha1"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/chromedp/cdproto/emulation"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
 )
 
+// DateLayout is the format of Holiday.Date
+const DateLayout = "2006-01-02"
+
 type Holiday struct {
-	Date   string   `json:"date"`
-	Day    string   `json:"day"`
-	Name   string   `json:"name"`
-	States []string `json:"states"`
+	Date   string   `json:"date" yaml:"date"`
+	Day    string   `json:"day" yaml:"day"`
+	Name   string   `json:"name" yaml:"name"`
+	States []string `json:"states" yaml:"states"`
+	// InLieu marks a replacement holiday ("cuti ganti") gazetted because
+	// the original fell on a weekend. OriginalDate is that original date,
+	// when the name says which it was.
+	InLieu       bool   `json:"inLieu,omitempty" yaml:"inLieu,omitempty"`
+	OriginalDate string `json:"originalDate,omitempty" yaml:"originalDate,omitempty"`
+	// ObservedDays is set by Consolidate only when the merged states'
+	// pages disagreed on Day, mapping each state to the day it reported.
+	ObservedDays map[string]string `json:"observedDays,omitempty" yaml:"observedDays,omitempty"`
+	// Type is TypePublic or TypeObservance when the page says which the row
+	// is, and empty when it doesn't.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// OnWeekend is set when Date is a Saturday or Sunday, so the holiday
+	// doesn't add a day off unless a replacement is gazetted.
+	OnWeekend bool `json:"onWeekend,omitempty" yaml:"onWeekend,omitempty"`
+	// NameEn and NameMs are Name in English and Bahasa Malaysia. NameMs is
+	// empty for holidays without a known translation.
+	NameEn string `json:"nameEn,omitempty" yaml:"nameEn,omitempty"`
+	NameMs string `json:"nameMs,omitempty" yaml:"nameMs,omitempty"`
+	// Note is the footnote the page attached to the name with a marker
+	// like "*", when it gave the footnote's text.
+	Note string `json:"note,omitempty" yaml:"note,omitempty"`
+	// ActualDate and ObservedDate are set by Consolidate when these states
+	// keep the holiday a few days off the date most states do, e.g. a day
+	// later after a different moon sighting: ActualDate is the majority's
+	// date and ObservedDate is Date.
+	ActualDate   string `json:"actualDate,omitempty" yaml:"actualDate,omitempty"`
+	ObservedDate string `json:"observedDate,omitempty" yaml:"observedDate,omitempty"`
+	// HijriDate is Date in the tabular Islamic calendar, set by
+	// AddHijriDates. It can be a day off the official Malaysian date; see
+	// ToHijri.
+	HijriDate string `json:"hijriDate,omitempty" yaml:"hijriDate,omitempty"`
+}
+
+// Holiday types, from the optional fourth column of a state's table or,
+// failing that, the row's class
+const (
+	TypePublic     = "public"
+	TypeObservance = "observance"
+)
+
+// Gazetted reports whether h is an official day off. Holidays of unknown
+// type count as gazetted, since the tables only list observances
+// separately when they list them at all.
+func (h Holiday) Gazetted() bool {
+	return h.Type != TypeObservance
+}
+
+// classifyType maps the text of a type column to TypePublic or
+// TypeObservance, or "" if it isn't recognised.
+func classifyType(cell string) string {
+	c := strings.ToLower(cell)
+	switch {
+	case strings.Contains(c, "observance"), strings.Contains(c, "not a public"),
+		strings.Contains(c, "regional"), strings.Contains(c, "optional"):
+		return TypeObservance
+	case strings.Contains(c, "public"), strings.Contains(c, "gazetted"), strings.Contains(c, "state holiday"):
+		return TypePublic
+	}
+	return ""
+}
+
+// observanceClass matches the classes a table row carries when the site
+// styles it as an observance rather than giving it a type column
+var observanceClass = regexp.MustCompile(`(?i)observance|optional|regional|not-public`)
+
+// ErrInvalidHoliday marks holidays rejected by Validate or rows that could
+// not be turned into a holiday at all.
+var ErrInvalidHoliday = errors.New("invalid holiday")
+
+// Page load failures from FetchState and FetchAll wrap one of these, so
+// callers can tell them apart with errors.Is. ParseHolidays, which loads
+// nothing, only uses ErrNoRows and ErrParse.
+var (
+	// ErrNavigation means the page couldn't be loaded or read
+	ErrNavigation = errors.New("navigation failed")
+	// ErrTimeout means the page didn't finish within WithTimeout
+	ErrTimeout = errors.New("page load timed out")
+	// ErrNoRows means the page loaded but had no holiday table for the
+	// year, usually because the site changed or the year isn't published
+	ErrNoRows = errors.New("no holiday rows")
+	// ErrParse means the page loaded but couldn't be read with the
+	// configured layout, e.g. a bad WithYearHeaderRegex
+	ErrParse = errors.New("parsing failed")
+	// ErrTooManyFailures means FetchAll gave up after WithMaxFailures
+	// states failed
+	ErrTooManyFailures = errors.New("too many failed states")
+)
+
+// FetchError is how FetchAll reports a state that failed, so callers can
+// tell which one without parsing the message. It unwraps to the cause.
+type FetchError struct {
+	State string
+	Year  int
+	Err   error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("%s (%d): %v", e.State, e.Year, e.Err)
+}
+
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// loadError wraps err from loading state's page in ErrTimeout or
+// ErrNavigation
+func loadError(state string, err error) error {
+	kind := ErrNavigation
+	if errors.Is(err, context.DeadlineExceeded) {
+		kind = ErrTimeout
+	}
+	return fmt.Errorf("%w: loading %s: %w", kind, state, err)
+}
+
+// Validate checks that h has a YYYY-MM-DD date, a name and at least one
+// state. Errors wrap ErrInvalidHoliday.
+func (h Holiday) Validate() error {
+	if _, err := h.AsTime(); err != nil {
+		return fmt.Errorf("%w: date %q is not YYYY-MM-DD", ErrInvalidHoliday, h.Date)
+	}
+	if strings.TrimSpace(h.Name) == "" {
+		return fmt.Errorf("%w: %s has no name", ErrInvalidHoliday, h.Date)
+	}
+	if len(h.States) == 0 {
+		return fmt.Errorf("%w: %s %q has no states", ErrInvalidHoliday, h.Date, h.Name)
+	}
+	return nil
+}
+
+// AsTime parses Date (YYYY-MM-DD) into a UTC midnight time.Time
+func (h Holiday) AsTime() (time.Time, error) {
+	return time.Parse(DateLayout, h.Date)
+}
+
+// Weekday is the full English name of Date's day of the week, e.g.
+// "Wednesday", or "" if Date doesn't parse. Unlike Day it doesn't depend
+// on how the source page spelled it.
+func (h Holiday) Weekday() string {
+	t, err := h.AsTime()
+	if err != nil {
+		return ""
+	}
+	return t.Weekday().String()
+}
+
+// ID is a stable identifier for h: the hex SHA-1 of its date and
+// consolidation name key, so it doesn't depend on which states observe it
+// or on spelling noise Consolidate already ignores. The key comes from the
+// English NameEn when it's set, so Localize doesn't change IDs either. It is
+// only as stable as the canonical name, so a names.yaml alias that changes a
+// holiday's name key changes its ID too.
+func (h Holiday) ID() string {
+	sum := sha1.Sum([]byte(h.Date + "|" + nameKey(cmp.Or(h.NameEn, h.Name))))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReformatDates returns holidays with Date, OriginalDate, ActualDate and
+// ObservedDate rewritten in layout, a time.Format layout, for display. Dates
+// that don't parse are left as they are. The result no longer passes
+// Validate, so only reformat just before writing.
+func ReformatDates(holidays []Holiday, layout string) []Holiday {
+	out := make([]Holiday, len(holidays))
+	for i, h := range holidays {
+		if t, err := time.Parse(DateLayout, h.Date); err == nil {
+			h.Date = t.Format(layout)
+		}
+		if t, err := time.Parse(DateLayout, h.OriginalDate); err == nil {
+			h.OriginalDate = t.Format(layout)
+		}
+		if t, err := time.Parse(DateLayout, h.ActualDate); err == nil {
+			h.ActualDate = t.Format(layout)
+		}
+		if t, err := time.Parse(DateLayout, h.ObservedDate); err == nil {
+			h.ObservedDate = t.Format(layout)
+		}
+		out[i] = h
+	}
+	return out
+}
+
+// RecomputeDays overwrites each holiday's Day with Weekday, leaving Day
+// alone where Date doesn't parse.
+func RecomputeDays(holidays []Holiday) []Holiday {
+	out := make([]Holiday, len(holidays))
+	for i, h := range holidays {
+		if wd := h.Weekday(); wd != "" {
+			h.Day = wd
+		}
+		out[i] = h
+	}
+	return out
 }
 
 type Scraper struct {
+	parent        context.Context
+	baseURL       string
+	headless      bool
+	retries       int
+	retryBackoff  time.Duration
+	timeout       time.Duration
+	concurrency   int
+	cacheDir      string
+	userAgent     string
+	proxy         string
+	chromePath    string
+	strict        bool
+	refresh       bool
+	maxAge        time.Duration
+	progress      func(Progress)
+	rawRows       func(state string, year int, rows [][]string)
+	fetchStats    func(FetchStats)
+	tableSelector string
+	yearHeader    string
+	blocking      bool
+	maxFailures   int
+	delay         time.Duration
+
+	// limiter spaces out live page loads across tabs; nil when delay is 0
+	limiter *rate.Limiter
+
+	// failures counts failed states over the Scraper's life, for maxFailures
+	failures atomic.Int64
+
 	ctx         context.Context
 	cancel      context.CancelFunc
 	allocCancel context.CancelFunc
 }
 
-// NewScraper initializes chromedp with sensible defaults
-func NewScraper(headless bool) *Scraper {
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", headless),
+// DefaultTableSelector matches the holiday table on each state page
+const DefaultTableSelector = "table.publicholidays"
+
+// settleDelay is how long a page gets to finish rendering when the holiday
+// table never matched its selector
+const settleDelay = 2 * time.Second
+
+// DefaultUserAgent is a current desktop Chrome on Windows, so the site
+// serves the same markup it shows regular visitors.
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/140.0.0.0 Safari/537.36"
+
+// NewScraper initializes chromedp with sensible defaults, adjusted by opts.
+// If the browser can't be found or started, fetches fail later with
+// navigation errors; use StartScraper to learn about that up front.
+func NewScraper(opts ...Option) *Scraper {
+	s, _ := newScraper(opts)
+	return s
+}
+
+// StartScraper is NewScraper, but checks the browser first: if it can't be
+// found (see FindChrome) or fails to start, it returns an error wrapping
+// ErrNoBrowser instead of a Scraper that can't fetch anything.
+func StartScraper(opts ...Option) (*Scraper, error) {
+	s, err := newScraper(opts)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// newScraper builds the Scraper and starts its browser. It always returns
+// a Scraper that must be closed, even with an error.
+func newScraper(opts []Option) (*Scraper, error) {
+	s := &Scraper{
+		parent:        context.Background(),
+		baseURL:       DefaultBaseURL,
+		retries:       3,
+		retryBackoff:  2 * time.Second,
+		timeout:       20 * time.Second,
+		concurrency:   4,
+		userAgent:     DefaultUserAgent,
+		tableSelector: DefaultTableSelector,
+		blocking:      true,
+		delay:         time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.delay > 0 {
+		s.limiter = rate.NewLimiter(rate.Every(s.delay), 1)
+	}
+
+	allocOpts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", s.headless),
 		chromedp.Flag("disable-gpu", true),
 		chromedp.Flag("blink-settings", "imagesEnabled=false"),
 	)
+	if s.proxy != "" {
+		allocOpts = append(allocOpts, chromedp.ProxyServer(s.proxy))
+	}
+	execPath, findErr := FindChrome(s.chromePath)
+	if findErr == nil {
+		allocOpts = append(allocOpts, chromedp.ExecPath(execPath))
+	} else if s.chromePath != "" {
+		allocOpts = append(allocOpts, chromedp.ExecPath(s.chromePath))
+	}
 
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	allocCtx, allocCancel := chromedp.NewExecAllocator(s.parent, allocOpts...)
 	ctx, cancel := chromedp.NewContext(allocCtx)
+	s.ctx, s.cancel, s.allocCancel = ctx, cancel, allocCancel
+	if findErr != nil {
+		return s, findErr
+	}
+
+	// Start the browser now; tabs opened from ctx before its first Run
+	// would each launch a browser of their own
+	var err error
+	if s.blocking {
+		err = blockResources(ctx)
+	} else {
+		err = chromedp.Run(ctx)
+	}
+	if err != nil && s.parent.Err() == nil {
+		return s, fmt.Errorf("%w: starting %s: %w", ErrNoBrowser, execPath, err)
+	}
+	return s, s.parent.Err()
+}
+
+func (s *Scraper) Close() {
+	s.cancel()
+	s.allocCancel()
+}
 
-	// Block heavy resources
-	_ = chromedp.Run(ctx,
+// blockResources stops the tab behind ctx from loading heavy resources
+func blockResources(ctx context.Context) error {
+	return chromedp.Run(ctx,
 		network.Enable(),
 		network.SetBlockedURLs([]string{
 			"*.png", "*.jpg", "*.jpeg", "*.gif",
 			"*.woff", "*.ttf", "*.svg", "*.css",
 		}),
 	)
+}
 
-	return &Scraper{ctx: ctx, cancel: cancel, allocCancel: allocCancel}
+// newTab opens another tab in the shared browser, so parallel workers don't
+// navigate over each other.
+func (s *Scraper) newTab() (context.Context, context.CancelFunc) {
+	ctx, cancel := chromedp.NewContext(s.ctx)
+	if s.blocking {
+		_ = blockResources(ctx)
+	}
+	return ctx, cancel
 }
 
-func (s *Scraper) Close() {
-	s.cancel()
-	s.allocCancel()
+// checkTabs warns if pages other than the browser's first tab are still
+// open, which means a tab context wasn't cancelled
+func (s *Scraper) checkTabs() {
+	if s.ctx.Err() != nil {
+		return
+	}
+	targets, err := chromedp.Targets(s.ctx)
+	if err != nil {
+		return
+	}
+	pages := 0
+	for _, t := range targets {
+		if t.Type == "page" {
+			pages++
+		}
+	}
+	if pages > 1 {
+		slog.Warn(fmt.Sprintf("⚠️  %d browser tabs still open after fetching; expected 1", pages),
+			"tabs", pages)
+	}
 }
 
-// FetchState scrapes one state page (national excluded)
-func (s *Scraper) FetchState(state string, year int) ([]Holiday, error) {
-	url := buildURL(state, year)
+// layout is how s finds a year's table on a page
+func (s *Scraper) layout() layout {
+	return layout{selector: s.tableSelector, yearHeader: s.yearHeader}
+}
 
-	// per-page timeout
-	ctx, cancel := context.WithTimeout(s.ctx, 20*time.Second)
+// CheckPage loads state's page for year without parsing holidays and
+// reports whether it still has the year header, holiday table and rows
+// FetchState depends on. It's a cheap canary for site redesigns.
+func (s *Scraper) CheckPage(ctx context.Context, state string, year int) (PageCheck, error) {
+	url, html, err := s.loadPage(ctx, state, year)
+	if err != nil {
+		return PageCheck{URL: url}, err
+	}
+	found, err := checkHTML(html, year, s.layout())
+	found.URL = url
+	if err != nil {
+		return found, fmt.Errorf("%w: checking %s (%d): %w", ErrParse, state, year, err)
+	}
+	return found, nil
+}
+
+// LatestYear reports the latest year the site publishes holidays for, read
+// from the year headers with a holiday table on state's page for the
+// current year. Late in a year that page usually lists next year's too.
+func (s *Scraper) LatestYear(ctx context.Context, state string) (int, error) {
+	year := time.Now().Year()
+	url, html, err := s.loadPage(ctx, state, year)
+	if err != nil {
+		return 0, err
+	}
+	years, err := publishedYears(html, s.layout())
+	if err != nil {
+		return 0, fmt.Errorf("%w: reading years on %s: %w", ErrParse, url, err)
+	}
+	if len(years) == 0 {
+		return 0, fmt.Errorf("%w: no year with a holiday table on %s", ErrNoRows, url)
+	}
+	return slices.Max(years), nil
+}
+
+// loadPage opens state's page for year in a tab of its own and returns its
+// URL and HTML, without waiting longer than half the timeout for the table.
+// Like fetchState, it waits its turn on the limiter first.
+func (s *Scraper) loadPage(ctx context.Context, state string, year int) (url, html string, err error) {
+	url = buildURL(s.baseURL, state, year)
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return url, "", err
+		}
+	}
+	tab, cancel := s.newTab()
 	defer cancel()
+	pageCtx, pageCancel := context.WithTimeout(tab, s.timeout)
+	defer pageCancel()
+	stop := context.AfterFunc(ctx, pageCancel)
+	defer stop()
 
-	var rows [][]string
-	err := chromedp.Run(ctx,
-		chromedp.Navigate(url),
-		chromedp.WaitVisible("table.publicholidays", chromedp.ByQuery),
-		chromedp.Evaluate(fmt.Sprintf(`
-			(() => {
-				// Find the header for the requested year
-				const yearHeader = Array.from(document.querySelectorAll("h2"))
-					.find(h => h.innerText.includes("%d"));
-				if (!yearHeader) return [];
-
-				// Table immediately after the h2
-				const table = yearHeader.nextElementSibling;
-				if (!table || !table.classList.contains("publicholidays")) return [];
-
-				const trs = Array.from(table.querySelectorAll("tbody tr"));
-				return trs.map(tr => {
-					const tds = Array.from(tr.querySelectorAll("td")).map(td => td.innerText.trim());
-					return tds;
-				});
-			})()
-		`, year), &rows),
-	)
+	var actions []chromedp.Action
+	if s.userAgent != "" {
+		actions = append(actions, emulation.SetUserAgentOverride(s.userAgent))
+	}
+	actions = append(actions, chromedp.Navigate(url), chromedp.WaitReady("body", chromedp.ByQuery))
+	if err := chromedp.Run(pageCtx, actions...); err != nil {
+		return url, "", loadError(state, err)
+	}
+	// the table may render after load, or be missing, which callers check
+	waitCtx, waitCancel := context.WithTimeout(pageCtx, s.timeout/2)
+	_ = chromedp.Run(waitCtx, chromedp.WaitVisible(s.tableSelector, chromedp.ByQuery))
+	waitCancel()
+
+	if err := chromedp.Run(pageCtx, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+		return url, "", loadError(state, err)
+	}
+	return url, html, nil
+}
+
+// FetchState scrapes one state page, retrying failed page loads with
+// exponential backoff. Errors wrap ErrNavigation, ErrTimeout, ErrNoRows,
+// ErrParse or ErrInvalidHoliday; the last three are not retried. Passing
+// National fetches the nationwide page instead; see buildURL. Cancelling
+// ctx, or reaching its deadline, abandons the page and any remaining
+// retries.
+func (s *Scraper) FetchState(ctx context.Context, state string, year int) ([]Holiday, error) {
+	// a tab of its own, closed when done, so repeated or concurrent calls
+	// don't pile up targets
+	tab, cancel := s.newTab()
+	defer cancel()
+	return s.fetchWithRetry(ctx, tab, state, year)
+}
+
+// retryable reports whether reloading the page might fix err. A page that
+// loaded but had no rows, or parsed badly, won't improve on reload.
+func retryable(err error) bool {
+	return !errors.Is(err, ErrNoRows) && !errors.Is(err, ErrParse) && !errors.Is(err, ErrInvalidHoliday)
+}
+
+// FetchStats describes one FetchState call, or one state in FetchAll, once
+// it has finished, retries included
+type FetchStats struct {
+	State string
+	Year  int
+	// Holidays is how many holidays were read; Cached is set if they came
+	// from the holiday cache without loading the page
+	Holidays int
+	Cached   bool
+	Attempts int
+	Duration time.Duration
+	Err      error
+}
+
+// fetchWithRetry is FetchState in the browser tab tab, bounded by ctx
+func (s *Scraper) fetchWithRetry(ctx, tab context.Context, state string, year int) (holidays []Holiday, err error) {
+	stats := FetchStats{State: state, Year: year}
+	if s.fetchStats != nil {
+		start := time.Now()
+		defer func() {
+			stats.Holidays, stats.Duration, stats.Err = len(holidays), time.Since(start), err
+			s.fetchStats(stats)
+		}()
+	}
+
+	if holidays, ok := s.loadCached(state, year); ok {
+		stats.Cached = true
+		return holidays, nil
+	}
+
+	backoff := s.retryBackoff
+	for attempt := 0; ; attempt++ {
+		stats.Attempts++
+		holidays, err = s.fetchState(ctx, tab, state, year)
+		if err == nil {
+			s.saveCached(state, year, holidays)
+			return holidays, nil
+		}
+		if attempt >= s.retries || ctx.Err() != nil || tab.Err() != nil || !retryable(err) {
+			return holidays, err
+		}
+
+		slog.Warn(fmt.Sprintf("🔁 Retrying %s (%d) in %s [%d/%d]: %v", state, year, backoff, attempt+1, s.retries, err),
+			"state", state, "year", year, "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (s *Scraper) fetchState(parent, tab context.Context, state string, year int) ([]Holiday, error) {
+	url := buildURL(s.baseURL, state, year)
+
+	// Prefer a saved snapshot of the page; otherwise save one after loading
+	snapshot := s.snapshotPath(state, year)
+	fromSnapshot := false
+	if snapshot != "" {
+		if abs, err := filepath.Abs(snapshot); err == nil {
+			if s.fresh(abs) {
+				url = "file://" + filepath.ToSlash(abs)
+				fromSnapshot = true
+				slog.Info(fmt.Sprintf("📂 Loading %s (%d) from %s", state, year, snapshot),
+					"state", state, "year", year, "snapshot", snapshot)
+			}
+		}
+	}
+
+	// per-page timeout, cut short if the caller's context ends first. The
+	// chromedp tab lives in tab, so parent can't be used directly.
+	ctx, cancel := context.WithTimeout(tab, s.timeout)
+	defer cancel()
+	stop := context.AfterFunc(parent, cancel)
+	defer stop()
+
+	var html string
+	var setup []chromedp.Action
+	if s.userAgent != "" {
+		setup = append(setup, emulation.SetUserAgentOverride(s.userAgent))
+	}
+	setup = append(setup, chromedp.Navigate(url))
+	if s.limiter != nil && !fromSnapshot {
+		if err := s.limiter.Wait(parent); err != nil {
+			return nil, err
+		}
+	}
+	if err := chromedp.Run(ctx, setup...); err != nil {
+		return nil, loadError(state, err)
+	}
+
+	// Give the table half the page budget to appear. If it doesn't, the
+	// site may have renamed its classes, so settle for any table under the
+	// year header once the document has loaded.
+	fallback := false
+	waitCtx, waitCancel := context.WithTimeout(ctx, s.timeout/2)
+	err := chromedp.Run(waitCtx, chromedp.WaitVisible(s.tableSelector, chromedp.ByQuery))
+	waitCancel()
 	if err != nil {
-		return nil, fmt.Errorf("error loading %s: %w", state, err)
+		if ctx.Err() != nil {
+			return nil, loadError(state, err)
+		}
+		slog.Warn(fmt.Sprintf("⚠️  %q not found on %s (%d); falling back to any table once the page settles", s.tableSelector, state, year),
+			"state", state, "year", year, "selector", s.tableSelector)
+		fallback = true
+		if err := chromedp.Run(ctx, chromedp.WaitReady("body", chromedp.ByQuery), chromedp.Sleep(settleDelay)); err != nil {
+			return nil, loadError(state, err)
+		}
 	}
 
-	if len(rows) == 0 {
-		log.Printf("⚠️  No rows found for %s in %d; page may have changed", state, year)
-		return nil, nil
+	l := s.layout()
+	if fallback {
+		l.selector = ""
+	}
+	if err := expandTable(ctx, state, year, cmp.Or(l.selector, "table")); err != nil {
+		return nil, loadError(state, err)
+	}
+
+	// Chrome only loads the page; reading the table is the same Go code
+	// ParseHolidays runs on saved HTML
+	if err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+		return nil, loadError(state, err)
+	}
+	if snapshot != "" && !fromSnapshot {
+		if err := saveSnapshot(snapshot, html); err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  Could not save snapshot for %s (%d): %v", state, year, err),
+				"state", state, "year", year, "error", err)
+		}
+	}
+
+	var onRows func([][]string)
+	if s.rawRows != nil {
+		onRows = func(rows [][]string) { s.rawRows(state, year, rows) }
+	}
+	holidays, err := parseHTML(html, state, year, l, onRows)
+	if errors.Is(err, ErrNoRows) || errors.Is(err, ErrParse) || (err != nil && s.strict) {
+		return nil, err
+	}
+
+	slog.Info(fmt.Sprintf("✅ Fetched %d rows for %s (%d)", len(holidays), state, year),
+		"state", state, "year", year, "rows", len(holidays))
+	return holidays, nil
+}
+
+// expandRounds and expandWait bound expandTable: how many times it clicks
+// or scrolls, and how long it gives new rows to render each time
+const (
+	expandRounds = 5
+	expandWait   = 500 * time.Millisecond
+)
+
+// expandJS counts the rows of tables matching the selector. In "probe"
+// mode it returns -1 instead when there's no "show more" control or
+// lazy-loading markup, so complete tables cost one Evaluate; in "expand"
+// mode it first clicks the control and scrolls to the bottom. Links that
+// would navigate away are never clicked.
+const expandJS = `((selector, mode) => {
+	const rows = () => document.querySelectorAll(selector + " tbody tr").length;
+	const more = Array.from(document.querySelectorAll('button, [role=button], a:not([href]), a[href^="#"], a[href^="javascript:"]'))
+		.find(el => el.offsetParent !== null && /show more|load more|view all|see all|lihat lagi/i.test(el.innerText));
+	const lazy = document.querySelector("[data-lazy], .infinite-scroll, .load-more");
+	if (mode === "probe" && !more && !lazy) return -1;
+	if (mode === "expand") {
+		if (more) more.click();
+		window.scrollTo(0, document.body.scrollHeight);
+	}
+	return rows();
+})(%s, %q)`
+
+// expandTable reveals rows a page only renders after a click or a scroll,
+// clicking and scrolling until the row count stops growing. It does nothing
+// on pages without a "show more" control or lazy-loading markup.
+func expandTable(ctx context.Context, state string, year int, selector string) error {
+	sel, _ := json.Marshal(selector)
+	var before int
+	if err := chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(expandJS, sel, "probe"), &before)); err != nil {
+		return err
+	}
+	if before < 0 {
+		return nil
+	}
+
+	rows := before
+	for range expandRounds {
+		var n int
+		if err := chromedp.Run(ctx,
+			chromedp.Evaluate(fmt.Sprintf(expandJS, sel, "expand"), nil),
+			chromedp.Sleep(expandWait),
+			chromedp.Evaluate(fmt.Sprintf(expandJS, sel, "count"), &n),
+		); err != nil {
+			return err
+		}
+		if n <= rows {
+			break
+		}
+		rows = n
+	}
+	if rows > before {
+		slog.Info(fmt.Sprintf("📜 Expanded the %s (%d) table from %d to %d rows", state, year, before, rows),
+			"state", state, "year", year, "before", before, "after", rows)
 	}
+	return nil
+}
 
+// ParseRows turns table cells extracted from a state page (date, day, name,
+// ...) into holidays for state. Rows that are too short are ignored, and a
+// row repeating an earlier date and name is dropped. Rows whose date can't
+// be parsed, or that fail Validate, are logged and skipped; the returned
+// error (wrapping ErrInvalidHoliday) lists them so strict callers can refuse
+// the page. It does no I/O, so it can be run against saved rows.
+func ParseRows(rows [][]string, state string, year int) ([]Holiday, error) {
 	var holidays []Holiday
+	var errs []error
+	// a row the page lists twice must not count twice for the state
+	seen := map[string]bool{}
+	notes := footnotes(rows)
 	for _, r := range rows {
 		if len(r) < 3 {
 			continue
 		}
-		dateStr, err := normalizeDate(r[0], year)
+		dates, err := normalizeDates(r[0], year)
 		if err != nil {
-			log.Printf("⚠️  Skipping row with unparseable date %q in %s (%d): %v", r[0], state, year, err)
+			slog.Warn(fmt.Sprintf("⚠️  Skipping row with unparseable date %q in %s (%d): %v", r[0], state, year, err),
+				"state", state, "year", year, "date", r[0], "error", err)
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrInvalidHoliday, state, err))
 			continue
 		}
 		day := r[1]
-		name := r[2]
+		// "Deepavali*" must key the same as a clean "Deepavali"
+		name, marker := stripFootnote(r[2])
+		name = CanonicalizeName(name)
 
-		holidays = append(holidays, Holiday{
-			Date:   dateStr,
-			Day:    day,
-			Name:   name,
-			States: []string{normalizeState(state)},
-		})
+		for _, dateStr := range dates {
+			for _, st := range normalizeStates(state) {
+				h := Holiday{
+					Date:   dateStr,
+					Day:    day,
+					Name:   name,
+					States: []string{st},
+				}
+				h.InLieu, h.OriginalDate = detectInLieu(name, year)
+				if marker != "" {
+					h.Note = notes[marker]
+				}
+				if len(r) > 3 {
+					h.Type = classifyType(r[3])
+				}
+				// the Day cell of a range row covers the whole span
+				if len(dates) > 1 {
+					if wd := h.Weekday(); wd != "" {
+						h.Day = wd
+					}
+				}
+				h.OnWeekend = onWeekend(h.Date)
+				h.NameEn, h.NameMs = translateName(h.Name)
+				if t, err := h.AsTime(); err == nil && !dayMatches(h.Day, t.Weekday()) {
+					slog.Warn(fmt.Sprintf("⚠️  %s %q in %s is listed as %s but falls on a %s", h.Date, h.Name, state, h.Day, t.Weekday()),
+						"state", state, "year", year, "date", h.Date, "name", h.Name, "day", h.Day, "weekday", t.Weekday().String())
+				}
+				if err := h.Validate(); err != nil {
+					slog.Warn(fmt.Sprintf("⚠️  Skipping invalid holiday in %s (%d): %v", state, year, err),
+						"state", state, "year", year, "date", h.Date, "name", h.Name, "error", err)
+					errs = append(errs, fmt.Errorf("%s: %w", state, err))
+					continue
+				}
+				key := h.Date + "|" + st + "|" + nameKey(h.Name)
+				if seen[key] {
+					slog.Info(fmt.Sprintf("🔂 Dropping duplicate row %s %q in %s (%d)", h.Date, h.Name, state, year),
+						"state", state, "year", year, "date", h.Date, "name", h.Name)
+					continue
+				}
+				seen[key] = true
+				holidays = append(holidays, h)
+			}
+		}
 	}
+	return holidays, errors.Join(errs...)
+}
 
-	log.Printf("✅ Fetched %d rows for %s (%d)", len(holidays), state, year)
-	return holidays, nil
+// Progress is FetchAll's position within one year, reported when a state
+// starts (State is the one starting) and when it finishes.
+type Progress struct {
+	Year  int
+	State string
+	Done  int
+	Total int
+}
+
+// FetchAll scrapes every state in states for year and returns the
+// consolidated result. Up to WithConcurrency states are fetched at once,
+// each worker in its own tab. A failing state does not stop the run; its
+// error is collected as a *FetchError and all failures are returned
+// together alongside whatever was fetched successfully. Once ctx is
+// cancelled no new states are started and pages in flight are abandoned.
+func (s *Scraper) FetchAll(ctx context.Context, year int, states []string) ([]Holiday, error) {
+	workers := s.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(states) {
+		workers = len(states)
+	}
+
+	// Results are stored by state index so consolidation sees them in the
+	// same order regardless of which worker finished first.
+	results := make([][]Holiday, len(states))
+	errs := make([]error, len(states))
+
+	var mu sync.Mutex
+	done := 0
+	report := func(st string, finished bool) {
+		if s.progress == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if finished {
+			done++
+		}
+		s.progress(Progress{Year: year, State: st, Done: done, Total: len(states)})
+	}
+
+	// abort is closed once failures reach WithMaxFailures
+	abort := make(chan struct{})
+	var abortOnce sync.Once
+	if s.maxFailures > 0 && s.failures.Load() >= int64(s.maxFailures) {
+		abortOnce.Do(func() { close(abort) })
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tab, cancel := s.newTab()
+			defer cancel()
+
+			for i := range jobs {
+				st := states[i]
+				slog.Info(fmt.Sprintf("🌐 [%d/%d] Fetching %s (%d)…", i+1, len(states), st, year),
+					"state", st, "year", year)
+				report(st, false)
+
+				holidays, err := s.fetchWithRetry(ctx, tab, st, year)
+				report(st, true)
+				if err != nil {
+					switch {
+					case errors.Is(err, ErrNoRows):
+						slog.Warn(fmt.Sprintf("⚠️  No rows found for %s in %d; page may have changed", st, year),
+							"state", st, "year", year, "rows", 0, "error", err)
+					case errors.Is(err, ErrTimeout):
+						slog.Error(fmt.Sprintf("⏱️  Timed out fetching %s (%d); try a longer -timeout", st, year),
+							"state", st, "year", year, "error", err)
+					default:
+						slog.Error(fmt.Sprintf("⛔ Failed to fetch %s (%d): %v", st, year, err),
+							"state", st, "year", year, "error", err)
+					}
+					errs[i] = &FetchError{State: st, Year: year, Err: err}
+					if n := s.failures.Add(1); s.maxFailures > 0 && n >= int64(s.maxFailures) {
+						abortOnce.Do(func() { close(abort) })
+					}
+					continue
+				}
+				results[i] = holidays
+			}
+		}()
+	}
+	// Stop handing out states once either context is cancelled or too many
+	// have failed; whatever finished by then is still returned
+	aborted := false
+dispatch:
+	for i := range states {
+		select {
+		case <-abort:
+			aborted = true
+			break dispatch
+		default:
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		case <-s.ctx.Done():
+			break dispatch
+		case <-abort:
+			aborted = true
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	s.checkTabs()
+
+	var all []Holiday
+	for _, r := range results {
+		all = append(all, r...)
+	}
+	if err := cmp.Or(ctx.Err(), s.ctx.Err()); err != nil {
+		errs = append(errs, fmt.Errorf("stopped early: %w", err))
+	} else if aborted {
+		errs = append(errs, fmt.Errorf("stopped early: %w (%d)", ErrTooManyFailures, s.maxFailures))
+	}
+	return Consolidate(all), errors.Join(errs...)
 }
 
-func buildURL(state string, year int) string {
-	// explicitly skip national
-	return fmt.Sprintf("https://publicholidays.com.my/%s/%d-dates/", state, year)
+// DefaultBaseURL is the site holidays are scraped from
+const DefaultBaseURL = "https://publicholidays.com.my"
+
+// PageURL is the page FetchState loads for state and year from the site at
+// base, e.g. DefaultBaseURL.
+func PageURL(base, state string, year int) string {
+	return buildURL(base, state, year)
+}
+
+func buildURL(base, state string, year int) string {
+	base = strings.TrimRight(base, "/")
+	// national holidays live at the site root, not under a state slug
+	if state == National {
+		return fmt.Sprintf("%s/%d-dates/", base, year)
+	}
+	return fmt.Sprintf("%s/%s/%d-dates/", base, state, year)
 }
 
 func normalizeDate(dateStr string, year int) (string, error) {
@@ -137,33 +932,135 @@ func normalizeDate(dateStr string, year int) (string, error) {
 	return "", fmt.Errorf("unrecognised date format: %q", dateStr)
 }
 
+// rangeSeparator splits spans like "1 - 2 Feb" or "30 Jan – 1 Feb"
+var rangeSeparator = regexp.MustCompile(`\s*(?:-|–|—|\bto\b)\s*`)
+
+// normalizeDates is normalizeDate for cells that may hold a range, returning
+// every date in it. "1 - 2 Feb" borrows the month from the end of the range;
+// a range running past December ends in the following year.
+func normalizeDates(dateStr string, year int) ([]string, error) {
+	parts := rangeSeparator.Split(strings.TrimSpace(dateStr), -1)
+	if len(parts) != 2 {
+		d, err := normalizeDate(dateStr, year)
+		if err != nil {
+			return nil, err
+		}
+		return []string{d}, nil
+	}
+
+	endStr, err := normalizeDate(parts[1], year)
+	if err != nil {
+		return nil, err
+	}
+	end, _ := time.Parse(DateLayout, endStr)
+
+	startStr, err := normalizeDate(parts[0], year)
+	if err != nil {
+		// bare day number: same month as the end
+		day, convErr := strconv.Atoi(parts[0])
+		if convErr != nil {
+			return nil, err
+		}
+		startStr = fmt.Sprintf("%d-%02d-%02d", year, int(end.Month()), day)
+	}
+	start, err := time.Parse(DateLayout, startStr)
+	if err != nil {
+		return nil, fmt.Errorf("unrecognised date range: %q", dateStr)
+	}
+
+	if end.Before(start) {
+		end = end.AddDate(1, 0, 0)
+	}
+	if end.Sub(start) > 7*24*time.Hour {
+		return nil, fmt.Errorf("implausibly long date range: %q", dateStr)
+	}
+
+	var dates []string
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format(DateLayout))
+	}
+	return dates, nil
+}
+
+// onWeekend reports whether date falls on a Saturday or Sunday. States
+// with a Friday and Saturday weekend are not special-cased.
+func onWeekend(date string) bool {
+	t, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return false
+	}
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// dayMatches reports whether a scraped Day cell, full ("Saturday") or
+// abbreviated ("Sat"), names wd.
+func dayMatches(day string, wd time.Weekday) bool {
+	day = strings.ToLower(strings.TrimSpace(day))
+	return len(day) >= 3 && strings.HasPrefix(strings.ToLower(wd.String()), day)
+}
+
 func normalizeState(st string) string {
+	st = strings.TrimSpace(st)
+	if slug, ok := codeToSlug(st); ok {
+		return slug
+	}
 	st = strings.ToLower(st)
 	st = strings.ReplaceAll(st, " ", "-")
-	st = strings.ReplaceAll(st, "&", "and")
 
 	switch st {
 	case "malacca":
 		return "melaka"
 	case "kualalumpur":
 		return "kuala-lumpur"
-	case "putrajayaand-selangor", "putrajaya-selangor":
-		// handled elsewhere in consolidation
-		return "putrajaya"
 	}
 	return st
 }
 
+// combinedState separates the states in a cell naming more than one, like
+// "Putrajaya & Selangor" or "putrajaya-and-selangor"
+var combinedState = regexp.MustCompile(`(?i)\s*&\s*|\s*,\s*|[\s-]+and[\s-]+`)
+
+// normalizeStates is normalizeState for a cell that may combine several
+// states, returning each one so none is dropped.
+func normalizeStates(st string) []string {
+	var out []string
+	for _, part := range combinedState.Split(st, -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, normalizeState(part))
+		}
+	}
+	return out
+}
+
 func Consolidate(holidays []Holiday) []Holiday {
 	merged := make(map[string]Holiday)
+	// what each state's page said the day was, per key
+	days := make(map[string]map[string]string)
 
 	for _, h := range holidays {
 		// Key by date+name (ignore "day" since states may observe on diff days)
-		key := h.Date + "|" + h.Name
+		key := h.Date + "|" + nameKey(h.Name)
+
+		if days[key] == nil {
+			days[key] = map[string]string{}
+		}
+		for _, st := range h.States {
+			if d, ok := h.ObservedDays[st]; ok {
+				days[key][st] = d
+			} else {
+				days[key][st] = h.Day
+			}
+		}
 
 		if existing, ok := merged[key]; ok {
 			existing.States = append(existing.States, h.States...)
 			existing.States = unique(existing.States)
+			existing.Name = preferName(existing.Name, h.Name)
+			existing.Note = cmp.Or(existing.Note, h.Note)
+			// a day off in any state makes the merged entry one
+			if existing.Type != h.Type && (existing.Type == TypeObservance || h.Type == TypeObservance) {
+				existing.Type = TypePublic
+			}
 			merged[key] = existing
 		} else {
 			h.States = unique(h.States)
@@ -171,20 +1068,190 @@ func Consolidate(holidays []Holiday) []Holiday {
 		}
 	}
 
-	// Convert back to slice
+	// Convert back to slice, with states alphabetical so output doesn't
+	// depend on scrape order
 	result := make([]Holiday, 0, len(merged))
-	for _, h := range merged {
+	for key, h := range merged {
+		sort.Strings(h.States)
+		h.Day, h.ObservedDays = reconcileDays(h, days[key])
+		h.OnWeekend = onWeekend(h.Date)
+		h.NameEn, h.NameMs = translateName(h.Name)
 		result = append(result, h)
 	}
 
 	// Sort by date for readability
 	sort.Slice(result, func(i, j int) bool {
-		return result[i].Date < result[j].Date
+		return dateLess(result[i], result[j])
 	})
 
+	linkObserved(result)
 	return result
 }
 
+// observedWindow is how far apart two states' dates for the same holiday
+// can be for linkObserved to treat one as a shifted observance
+const observedWindow = 3 * 24 * time.Hour
+
+// linkObserved sets ActualDate and ObservedDate on entries whose holiday
+// other states observe within observedWindow of it. Same-named entries are
+// clustered by date first, so each year's (or each occurrence's) dates are
+// linked separately. In a cluster, the date the most states observe (the
+// earliest, on a tie) is the actual one. Entries sharing a state aren't
+// linked, since a state observing both dates has a two-day holiday rather
+// than a shifted one, and neither are in-lieu days.
+func linkObserved(holidays []Holiday) {
+	byName := map[string][]int{}
+	for i := range holidays {
+		holidays[i].ActualDate, holidays[i].ObservedDate = "", ""
+		if _, err := holidays[i].AsTime(); err != nil || holidays[i].InLieu {
+			continue
+		}
+		key := nameKey(holidays[i].Name)
+		byName[key] = append(byName[key], i)
+	}
+
+	for _, idx := range byName {
+		// idx is in date order, so a gap wider than the window ends one
+		// occurrence of the holiday and starts the next
+		start := 0
+		for k := 1; k <= len(idx); k++ {
+			if k < len(idx) {
+				prev, _ := holidays[idx[k-1]].AsTime()
+				t, _ := holidays[idx[k]].AsTime()
+				if t.Sub(prev) <= observedWindow {
+					continue
+				}
+			}
+			linkCluster(holidays, idx[start:k])
+			start = k
+		}
+	}
+}
+
+// linkCluster links the entries at idx, one occurrence of a holiday in
+// date order, to the date most of their states observe; see linkObserved
+func linkCluster(holidays []Holiday, idx []int) {
+	if len(idx) < 2 {
+		return
+	}
+	// the first of the widest wins a tie
+	actual := idx[0]
+	for _, i := range idx[1:] {
+		if len(holidays[i].States) > len(holidays[actual].States) {
+			actual = i
+		}
+	}
+	at, _ := holidays[actual].AsTime()
+	for _, i := range idx {
+		h := &holidays[i]
+		t, _ := h.AsTime()
+		if i == actual || t.Sub(at).Abs() > observedWindow ||
+			slices.ContainsFunc(h.States, func(st string) bool { return slices.Contains(holidays[actual].States, st) }) {
+			continue
+		}
+		h.ActualDate, h.ObservedDate = holidays[actual].Date, h.Date
+	}
+}
+
+// reconcileDays settles the Day of a merged holiday. If every state reported
+// the same day it is kept as-is. If they disagree, Day is recomputed from
+// the date so the record is internally consistent, and the per-state
+// reports are returned so the disagreement isn't lost.
+func reconcileDays(h Holiday, byState map[string]string) (string, map[string]string) {
+	distinct := map[string]bool{}
+	for _, d := range byState {
+		distinct[d] = true
+	}
+	if len(distinct) <= 1 {
+		return h.Day, nil
+	}
+	if t, err := h.AsTime(); err == nil {
+		return t.Weekday().String(), byState
+	}
+	return h.Day, byState
+}
+
+var (
+	parenthetical = regexp.MustCompile(`\s*\([^)]*\)`)
+	whitespace    = regexp.MustCompile(`\s+`)
+
+	// spellingVariants unifies spellings seen across state pages
+	spellingVariants = strings.NewReplacer(
+		"aidil fitri", "aidilfitri",
+		"idul fitri", "aidilfitri",
+		"aidil adha", "aidiladha",
+		"idul adha", "aidiladha",
+		"hari raya haji", "hari raya aidiladha",
+		"diwali", "deepavali",
+		"deepavali day", "deepavali",
+		"wesak", "vesak",
+		"nuzul al-quran", "nuzul quran",
+		"'", "",
+		"’", "",
+	)
+)
+
+// nameKey reduces a holiday name to the form used for consolidation, so
+// "Hari Raya Aidilfitri" and "Hari Raya Aidil Fitri (Day 1)" key the same.
+func nameKey(name string) string {
+	k := strings.ToLower(name)
+	k = parenthetical.ReplaceAllString(k, "")
+	k = whitespace.ReplaceAllString(k, " ")
+	k = strings.TrimSpace(k)
+	return spellingVariants.Replace(k)
+}
+
+var (
+	// inLieuMarker spots replacement-holiday wording in a name
+	inLieuMarker = regexp.MustCompile(`(?i)\bcuti\s+ganti\b|\bin\s+lieu\b|\bsubstitute\b|\breplacement\b|\bganti\b`)
+	// inLieuOf captures the date in "... in lieu of 1 Jun" / "ganti 1 Jun"
+	inLieuOf = regexp.MustCompile(`(?i)(?:\bof|\bfor|\bganti)\s+(\d{1,2}\s+[a-z]+)`)
+)
+
+// detectInLieu reports whether name marks a replacement holiday and, if the
+// name mentions it, the date being replaced.
+func detectInLieu(name string, year int) (bool, string) {
+	if !inLieuMarker.MatchString(name) {
+		return false, ""
+	}
+	if m := inLieuOf.FindStringSubmatch(name); m != nil {
+		if d, err := normalizeDate(m[1], year); err == nil {
+			return true, d
+		}
+	}
+	return true, ""
+}
+
+// preferName picks which of two names for the same holiday to keep: the
+// longer (usually more descriptive) one, alphabetically first on a tie so
+// the result doesn't depend on scrape order.
+func preferName(a, b string) string {
+	if len(b) > len(a) || (len(b) == len(a) && b < a) {
+		return b
+	}
+	return a
+}
+
+// dateLess orders holidays chronologically, falling back to comparing the
+// raw strings if either date doesn't parse. Holidays on the same date are
+// ordered by name, then states, so the order never depends on map
+// iteration.
+func dateLess(a, b Holiday) bool {
+	ta, errA := a.AsTime()
+	tb, errB := b.AsTime()
+	if errA != nil || errB != nil {
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+	} else if !ta.Equal(tb) {
+		return ta.Before(tb)
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return strings.Join(a.States, ",") < strings.Join(b.States, ",")
+}
+
 func unique(input []string) []string {
 	seen := map[string]bool{}
 	var out []string
@@ -199,23 +1266,110 @@ func unique(input []string) []string {
 
 // Save to JSON
 func SaveJSON(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteJSON)
+}
+
+// WriteJSON writes holidays to w as indented JSON
+func WriteJSON(w io.Writer, holidays []Holiday) error {
 	data, err := json.MarshalIndent(holidays, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	_, err = w.Write(data)
+	return err
 }
 
-// Save to CSV
-func SaveCSV(path string, holidays []Holiday) error {
-	f, err := os.Create(path)
+// SaveJSONL writes holidays to path as JSON Lines
+func SaveJSONL(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteJSONL)
+}
+
+// WriteJSONL writes holidays to w as JSON Lines: one compact object per
+// holiday, each ending in a newline, for jq -c and bulk loaders
+func WriteJSONL(w io.Writer, holidays []Holiday) error {
+	enc := json.NewEncoder(w)
+	for _, h := range holidays {
+		if err := enc.Encode(h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadJSON reads holidays previously written by SaveJSON
+func LoadJSON(path string) ([]Holiday, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer f.Close()
+	return ReadJSON(f)
+}
 
-	w := csv.NewWriter(f)
-	defer w.Flush()
+// ReadJSON decodes holidays from r, either a bare JSON array or one wrapped
+// in an Envelope.
+func ReadJSON(r io.Reader) ([]Holiday, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if len(raw) > 0 && raw[0] == '{' {
+		var env Envelope
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return nil, err
+		}
+		if env.SchemaVersion > SchemaVersion {
+			return nil, fmt.Errorf("schema version %d is newer than supported version %d", env.SchemaVersion, SchemaVersion)
+		}
+		return env.Holidays, nil
+	}
+	var holidays []Holiday
+	if err := json.Unmarshal(raw, &holidays); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// Save to YAML
+func SaveYAML(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteYAML)
+}
+
+// WriteYAML writes holidays to w as a YAML sequence
+func WriteYAML(w io.Writer, holidays []Holiday) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(holidays); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Save to CSV
+func SaveCSV(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteCSV)
+}
+
+// WriteCSV writes holidays to w as CSV, joining states with ";"
+func WriteCSV(out io.Writer, holidays []Holiday) error {
+	return writeDelimited(out, holidays, ',')
+}
+
+// Save to TSV
+func SaveTSV(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteTSV)
+}
+
+// WriteTSV writes holidays to w with the same columns as WriteCSV, but
+// tab-separated so names containing commas don't need quoting
+func WriteTSV(out io.Writer, holidays []Holiday) error {
+	return writeDelimited(out, holidays, '\t')
+}
+
+// writeDelimited backs WriteCSV and WriteTSV
+func writeDelimited(out io.Writer, holidays []Holiday, comma rune) error {
+	w := csv.NewWriter(out)
+	w.Comma = comma
 
 	if err := w.Write([]string{"Date", "Day", "Name", "States"}); err != nil {
 		return err
@@ -228,5 +1382,40 @@ func SaveCSV(path string, holidays []Holiday) error {
 		}
 	}
 
-	return nil
+	w.Flush()
+	return w.Error()
+}
+
+// saveFile writes holidays to path atomically with write
+func saveFile(path string, holidays []Holiday, write func(io.Writer, []Holiday) error) error {
+	return WriteFileAtomic(path, 0644, func(w io.Writer) error { return write(w, holidays) })
+}
+
+// WriteFileAtomic writes path with write by way of a temporary file in the
+// same directory, renamed over path only once write succeeds, so readers
+// never see a half-written file. The result gets permissions perm. On
+// failure path is left as it was.
+func WriteFileAtomic(path string, perm os.FileMode, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
 }
@@ -0,0 +1,546 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestConsolidateMergesNearIdenticalNames(t *testing.T) {
+	got := Consolidate([]Holiday{
+		{Date: "2025-03-31", Day: "Monday", Name: "Hari Raya Aidilfitri", States: []string{"johor"}},
+		{Date: "2025-03-31", Day: "Monday", Name: "Hari Raya  Aidil Fitri (Day 1)", States: []string{"selangor"}},
+	})
+
+	if len(got) != 1 {
+		t.Fatalf("got %d holidays, want 1: %+v", len(got), got)
+	}
+	if want := "Hari Raya  Aidil Fitri (Day 1)"; got[0].Name != want {
+		t.Errorf("Name = %q, want %q", got[0].Name, want)
+	}
+	if len(got[0].States) != 2 {
+		t.Errorf("States = %v, want johor and selangor", got[0].States)
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"Hari Raya Aidilfitri", "Hari Raya Aidilfitri (Day 1)"},
+		{"Hari Raya Haji", "Hari Raya Aidil Adha"},
+		{"Deepavali", "Diwali"},
+		{"Nuzul Al-Quran", "Nuzul Quran"},
+		{"Wesak Day", "  Vesak   Day "},
+	}
+	for _, tt := range tests {
+		if ka, kb := nameKey(tt.a), nameKey(tt.b); ka != kb {
+			t.Errorf("nameKey(%q) = %q, nameKey(%q) = %q; want equal", tt.a, ka, tt.b, kb)
+		}
+	}
+}
+
+func TestNormalizeDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "1 Jan", want: "2025-01-01"},
+		{in: "2 February", want: "2025-02-02"},
+		{in: "31 December", want: "2025-12-31"},
+		{in: "01 Jan", want: "2025-01-01"},
+		{in: "09 September", want: "2025-09-09"},
+		{in: "1 Jun", want: "2025-06-01"},
+		{in: "1 June", want: "2025-06-01"},
+		// Go only knows three-letter abbreviations, so the site's
+		// occasional "Sept" or "Febr" doesn't parse
+		{in: "1 Sept", wantErr: true},
+		{in: "1 Febr", wantErr: true},
+		// parsing happens without a year, in leap year 0, so 29 Feb is
+		// accepted for any year; Validate catches the impossible date
+		{in: "29 Feb", want: "2025-02-29"},
+		{in: "31 Apr", wantErr: true},
+		{in: "Jan 1", wantErr: true},
+		{in: "1/1", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "TBC", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeDate(tt.in, 2025)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeDate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeDate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDatesRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"1 Feb", []string{"2025-02-01"}},
+		{"1 - 2 Feb", []string{"2025-02-01", "2025-02-02"}},
+		{"30 Jan - 1 Feb", []string{"2025-01-30", "2025-01-31", "2025-02-01"}},
+		{"31 Dec – 1 Jan", []string{"2025-12-31", "2026-01-01"}},
+	}
+	for _, tt := range tests {
+		got, err := normalizeDates(tt.in, 2025)
+		if err != nil {
+			t.Errorf("normalizeDates(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("normalizeDates(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHolidayValidate(t *testing.T) {
+	tests := []struct {
+		h     Holiday
+		valid bool
+	}{
+		{Holiday{Date: "2025-01-01", Name: "New Year's Day", States: []string{"johor"}}, true},
+		{Holiday{Date: "2025-1---2-Feb", Name: "Broken", States: []string{"johor"}}, false},
+		{Holiday{Date: "2025-01-01", Name: "  ", States: []string{"johor"}}, false},
+		{Holiday{Date: "2025-01-01", Name: "New Year's Day"}, false},
+	}
+	for _, tt := range tests {
+		err := tt.h.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, want valid=%v", tt.h, err, tt.valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidHoliday) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidHoliday", tt.h, err)
+		}
+	}
+}
+
+func TestConsolidateSortsStates(t *testing.T) {
+	got := Consolidate([]Holiday{
+		{Date: "2025-05-01", Name: "Labour Day", States: []string{"terengganu"}},
+		{Date: "2025-05-01", Name: "Labour Day", States: []string{"johor", "selangor"}},
+		{Date: "2025-05-01", Name: "Labour Day", States: []string{"kedah"}},
+	})
+
+	want := []string{"johor", "kedah", "selangor", "terengganu"}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].States, want) {
+		t.Errorf("Consolidate() = %+v, want one holiday with States %v", got, want)
+	}
+}
+
+func TestDetectInLieu(t *testing.T) {
+	tests := []struct {
+		name     string
+		inLieu   bool
+		original string
+	}{
+		{"Deepavali (Cuti Ganti)", true, ""},
+		{"Hari Raya Haji Holiday (in lieu of 7 Jun)", true, "2025-06-07"},
+		{"Substitute Holiday for 1 June", true, "2025-06-01"},
+		{"Hari Raya Aidilfitri", false, ""},
+	}
+	for _, tt := range tests {
+		inLieu, original := detectInLieu(tt.name, 2025)
+		if inLieu != tt.inLieu || original != tt.original {
+			t.Errorf("detectInLieu(%q) = %v, %q; want %v, %q", tt.name, inLieu, original, tt.inLieu, tt.original)
+		}
+	}
+}
+
+func TestConsolidateDayMismatch(t *testing.T) {
+	got := Consolidate([]Holiday{
+		{Date: "2025-08-31", Day: "Sunday", Name: "National Day", States: []string{"johor"}},
+		{Date: "2025-08-31", Day: "Monday", Name: "National Day", States: []string{"kedah"}},
+	})
+
+	if len(got) != 1 {
+		t.Fatalf("got %d holidays, want 1: %+v", len(got), got)
+	}
+	if got[0].Day != "Sunday" {
+		t.Errorf("Day = %q, want it recomputed from the date as Sunday", got[0].Day)
+	}
+	want := map[string]string{"johor": "Sunday", "kedah": "Monday"}
+	if !reflect.DeepEqual(got[0].ObservedDays, want) {
+		t.Errorf("ObservedDays = %v, want %v", got[0].ObservedDays, want)
+	}
+
+	agreed := Consolidate([]Holiday{
+		{Date: "2025-08-31", Day: "Sun", Name: "National Day", States: []string{"johor"}},
+		{Date: "2025-08-31", Day: "Sun", Name: "National Day", States: []string{"kedah"}},
+	})
+	if agreed[0].Day != "Sun" || agreed[0].ObservedDays != nil {
+		t.Errorf("agreeing states: got Day %q, ObservedDays %v; want Sun, nil", agreed[0].Day, agreed[0].ObservedDays)
+	}
+}
+
+func TestParseRowsType(t *testing.T) {
+	got, err := ParseRows([][]string{
+		{"1 Jan", "Wednesday", "New Year's Day", "Public Holiday"},
+		{"14 Feb", "Friday", "Valentine's Day", "Observance"},
+		{"1 May", "Thursday", "Labour Day"},
+	}, "selangor", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{TypePublic, TypeObservance, ""}
+	for i, h := range got {
+		if h.Type != want[i] {
+			t.Errorf("%s: Type = %q, want %q", h.Name, h.Type, want[i])
+		}
+	}
+	if g := FilterGazetted(got); len(g) != 2 {
+		t.Errorf("FilterGazetted kept %d holidays, want 2", len(g))
+	}
+}
+
+func TestReadJSONEnvelope(t *testing.T) {
+	want := []Holiday{{Date: "2025-01-01", Day: "Wednesday", Name: "New Year's Day", States: []string{"selangor"}}}
+	var buf bytes.Buffer
+	if err := WriteJSONEnvelope(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadJSON(envelope) = %+v, want %+v", got, want)
+	}
+
+	if _, err := ReadJSON(strings.NewReader(`{"schemaVersion": 99, "holidays": []}`)); err == nil {
+		t.Error("ReadJSON accepted a newer schema version")
+	}
+}
+
+func TestNormalizeStatesCombined(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"selangor", []string{"selangor"}},
+		{"Kuala Lumpur", []string{"kuala-lumpur"}},
+		{"Putrajaya & Selangor", []string{"putrajaya", "selangor"}},
+		{"putrajaya-and-selangor", []string{"putrajaya", "selangor"}},
+		{"Malacca, Johor", []string{"melaka", "johor"}},
+	}
+	for _, tt := range tests {
+		if got := normalizeStates(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("normalizeStates(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRowsCombinedState(t *testing.T) {
+	rows := [][]string{{"1 Feb", "Saturday", "Federal Territory Day"}}
+	got, err := ParseRows(rows, "Putrajaya & Selangor", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := Consolidate(got)
+	if len(merged) != 1 || !reflect.DeepEqual(merged[0].States, []string{"putrajaya", "selangor"}) {
+		t.Errorf("ParseRows(combined) consolidated to %+v, want one holiday for putrajaya and selangor", merged)
+	}
+}
+
+func TestParseRowsOnWeekend(t *testing.T) {
+	rows := [][]string{
+		{"1 Feb", "Saturday", "Federal Territory Day"},
+		{"3 Feb", "Monday", "Some Holiday"},
+	}
+	got, err := ParseRows(rows, "kuala-lumpur", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].OnWeekend || got[1].OnWeekend {
+		t.Errorf("ParseRows OnWeekend = %+v, want only the Saturday flagged", got)
+	}
+	if kept := FilterWeekdays(got); len(kept) != 1 || kept[0].Date != "2025-02-03" {
+		t.Errorf("FilterWeekdays = %+v, want only 2025-02-03", kept)
+	}
+}
+
+func TestHolidayWeekday(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2025-08-31", "Sunday"},
+		{"2024-02-29", "Thursday"},
+		{"2025-13-01", ""},
+	}
+	for _, tt := range tests {
+		if got := (Holiday{Date: tt.date}).Weekday(); got != tt.want {
+			t.Errorf("Weekday(%s) = %q, want %q", tt.date, got, tt.want)
+		}
+	}
+
+	got := RecomputeDays([]Holiday{{Date: "2025-08-31", Day: "Sun"}, {Date: "bad", Day: "Mon"}})
+	if got[0].Day != "Sunday" || got[1].Day != "Mon" {
+		t.Errorf("RecomputeDays = %+v, want Sunday and the unparseable row untouched", got)
+	}
+}
+
+func TestLoadErrorKinds(t *testing.T) {
+	timeout := loadError("selangor", fmt.Errorf("waiting: %w", context.DeadlineExceeded))
+	if !errors.Is(timeout, ErrTimeout) || errors.Is(timeout, ErrNavigation) {
+		t.Errorf("loadError(deadline) = %v, want ErrTimeout", timeout)
+	}
+	nav := loadError("selangor", errors.New("net::ERR_NAME_NOT_RESOLVED"))
+	if !errors.Is(nav, ErrNavigation) || !retryable(nav) {
+		t.Errorf("loadError(dns) = %v, want retryable ErrNavigation", nav)
+	}
+	if retryable(fmt.Errorf("%w for selangor", ErrNoRows)) {
+		t.Error("ErrNoRows should not be retried")
+	}
+}
+
+func TestLoadPageWaitsForLimiter(t *testing.T) {
+	s := &Scraper{baseURL: DefaultBaseURL, limiter: rate.NewLimiter(rate.Every(time.Hour), 1)}
+	s.limiter.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// there's no browser, so getting past the limiter would fail differently
+	if _, _, err := s.loadPage(ctx, "selangor", 2025); err == nil || errors.Is(err, ErrNavigation) {
+		t.Errorf("loadPage with the limiter spent = %v, want it to wait and give up", err)
+	}
+}
+
+func TestParseRowsDropsDuplicates(t *testing.T) {
+	rows := [][]string{
+		{"1 May", "Thursday", "Labour Day"},
+		{"1 May", "Thursday", "Labour Day"},
+		{"1 May", "Thursday", "Labour  day"},
+		{"12 May", "Monday", "Wesak Day"},
+	}
+	got, err := ParseRows(rows, "selangor", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != "Labour Day" || got[1].Name != "Wesak Day" {
+		t.Errorf("ParseRows(duplicates) = %+v, want Labour Day and Wesak Day once each", got)
+	}
+}
+
+func TestReformatDates(t *testing.T) {
+	in := []Holiday{{Date: "2025-08-31", OriginalDate: "2025-08-30", Name: "National Day"}}
+	got := ReformatDates(in, "02/01/2006")
+	if got[0].Date != "31/08/2025" || got[0].OriginalDate != "30/08/2025" {
+		t.Errorf("ReformatDates = %+v", got[0])
+	}
+	if in[0].Date != "2025-08-31" {
+		t.Error("ReformatDates modified its input")
+	}
+}
+
+func TestConsolidateSameDateOrder(t *testing.T) {
+	in := []Holiday{
+		{Date: "2025-03-31", Day: "Monday", Name: "Sultan of Johor's Birthday", States: []string{"johor"}},
+		{Date: "2025-03-31", Day: "Monday", Name: "Hari Raya Aidilfitri", States: []string{"selangor"}},
+		{Date: "2025-03-30", Day: "Sunday", Name: "Eve", States: []string{"sabah"}},
+		{Date: "2025-03-31", Day: "Monday", Name: "Hari Raya Aidilfitri", States: []string{"johor"}},
+		{Date: "2025-03-31", Day: "Monday", Name: "Arbor Day", States: []string{"sarawak"}},
+	}
+	want := []string{"Eve", "Arbor Day", "Hari Raya Aidilfitri", "Sultan of Johor's Birthday"}
+	// map iteration order varies, so one lucky run proves little
+	for range 20 {
+		var got []string
+		for _, h := range Consolidate(in) {
+			got = append(got, h.Name)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Consolidate order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestConsolidateLinksShiftedObservance(t *testing.T) {
+	in := []Holiday{
+		{Date: "2025-06-06", Day: "Friday", Name: "Hari Raya Haji", States: []string{"selangor"}},
+		{Date: "2025-06-06", Day: "Friday", Name: "Hari Raya Haji", States: []string{"johor"}},
+		{Date: "2025-06-07", Day: "Saturday", Name: "Hari Raya Haji", States: []string{"kelantan"}},
+		// a state observing both days has a two-day holiday, not a shift
+		{Date: "2025-03-31", Day: "Monday", Name: "Hari Raya Aidilfitri", States: []string{"johor", "selangor"}},
+		{Date: "2025-04-01", Day: "Tuesday", Name: "Hari Raya Aidilfitri", States: []string{"selangor"}},
+		// too far apart to be the same observance
+		{Date: "2025-01-01", Day: "Wednesday", Name: "New Year's Day", States: []string{"johor"}},
+		{Date: "2025-01-10", Day: "Friday", Name: "New Year's Day", States: []string{"kelantan"}},
+	}
+	type link struct{ Date, Actual, Observed string }
+	want := []link{
+		{"2025-01-01", "", ""},
+		{"2025-01-10", "", ""},
+		{"2025-03-31", "", ""},
+		{"2025-04-01", "", ""},
+		{"2025-06-06", "", ""},
+		{"2025-06-07", "2025-06-06", "2025-06-07"},
+	}
+	var got []link
+	for _, h := range Consolidate(in) {
+		got = append(got, link{h.Date, h.ActualDate, h.ObservedDate})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Consolidate links = %v, want %v", got, want)
+	}
+}
+
+func TestConsolidateLinksShiftedObservanceEachYear(t *testing.T) {
+	in := []Holiday{
+		{Date: "2024-06-17", Day: "Monday", Name: "Hari Raya Haji", States: []string{"johor", "selangor"}},
+		{Date: "2024-06-18", Day: "Tuesday", Name: "Hari Raya Haji", States: []string{"kelantan"}},
+		{Date: "2025-06-06", Day: "Friday", Name: "Hari Raya Haji", States: []string{"selangor"}},
+		{Date: "2025-06-07", Day: "Saturday", Name: "Hari Raya Haji", States: []string{"kelantan"}},
+		{Date: "2025-06-06", Day: "Friday", Name: "Hari Raya Haji", States: []string{"johor"}},
+	}
+	type link struct{ Date, Actual, Observed string }
+	want := []link{
+		{"2024-06-17", "", ""},
+		{"2024-06-18", "2024-06-17", "2024-06-18"},
+		{"2025-06-06", "", ""},
+		{"2025-06-07", "2025-06-06", "2025-06-07"},
+	}
+	var got []link
+	for _, h := range Consolidate(in) {
+		got = append(got, link{h.Date, h.ActualDate, h.ObservedDate})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Consolidate links = %v, want %v", got, want)
+	}
+}
+
+func TestHolidayID(t *testing.T) {
+	h := Holiday{Date: "2025-12-31", Name: "New Year's Eve", States: []string{"johor"}}
+	same := []Holiday{
+		{Date: "2025-12-31", Name: "New Year's Eve", States: []string{"selangor", "sabah"}},
+		{Date: "2025-12-31", Name: "New Years Eve"},
+		{Date: "2025-12-31", Name: "Malam Tahun Baru", NameEn: "New Year's Eve"},
+	}
+	for _, o := range same {
+		if o.ID() != h.ID() {
+			t.Errorf("%+v: ID %s, want %s", o, o.ID(), h.ID())
+		}
+	}
+	if o := (Holiday{Date: "2026-12-31", Name: h.Name}); o.ID() == h.ID() {
+		t.Error("different dates gave the same ID")
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	holidays := []Holiday{
+		{Date: "2025-01-01", Day: "Wednesday", Name: "New Year's Day", States: []string{"selangor"}},
+		{Date: "2025-08-31", Day: "Sunday", Name: "National Day", States: []string{"johor", "selangor"}, OnWeekend: true},
+	}
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, holidays); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(holidays) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(holidays), buf.String())
+	}
+	var got []Holiday
+	for _, line := range lines {
+		var h Holiday
+		if err := json.Unmarshal([]byte(line), &h); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		got = append(got, h)
+	}
+	if !reflect.DeepEqual(got, holidays) {
+		t.Errorf("round trip gave %+v, want %+v", got, holidays)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "holidays.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed write leaves the old file and no temporary behind
+	boom := errors.New("boom")
+	err := WriteFileAtomic(path, 0600, func(w io.Writer) error {
+		io.WriteString(w, "half")
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("after a failed write the file holds %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+
+	if err := WriteFileAtomic(path, 0600, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" || info.Mode().Perm() != 0600 {
+		t.Errorf("got %q with mode %v, want \"new\" with 0600", data, info.Mode().Perm())
+	}
+}
+
+func TestParseRowsFootnotes(t *testing.T) {
+	got, err := ParseRows([][]string{
+		{"20 Oct", "Monday", "Deepavali*"},
+		{"25 Dec", "Thursday", "Christmas Day †"},
+		{"12 May", "Monday", "Wesak Day¹"},
+		{"1 May", "Thursday", "Labour Day [2]"},
+		{"* Except Sarawak"},
+		{"¹ Subject to change"},
+	}, "selangor", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type nameNote struct{ Name, Note string }
+	var names []nameNote
+	for _, h := range got {
+		names = append(names, nameNote{h.Name, h.Note})
+	}
+	want := []nameNote{
+		{"Deepavali", "Except Sarawak"},
+		{"Christmas Day", ""},
+		{"Wesak Day", "Subject to change"},
+		{"Labour Day", ""},
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+
+	// the starred name now merges with a clean one from another state
+	kl, err := ParseRows([][]string{{"20 Oct", "Monday", "Deepavali"}}, "kuala-lumpur", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := Consolidate(append(got[:1:1], kl...))
+	if len(merged) != 1 || merged[0].Note != "Except Sarawak" || len(merged[0].States) != 2 {
+		t.Errorf("Consolidate gave %+v, want one Deepavali for both states with the note", merged)
+	}
+}
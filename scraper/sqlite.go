@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite" // pure-Go driver, no cgo
+)
+
+// sqliteSchema is the holidays table, one row per holiday per state
+const sqliteSchema = `
+		CREATE TABLE IF NOT EXISTS holidays (
+			id    TEXT NOT NULL,
+			date  TEXT NOT NULL,
+			day   TEXT NOT NULL,
+			name  TEXT NOT NULL,
+			state TEXT NOT NULL,
+			PRIMARY KEY (id, state)
+		)`
+
+// SaveSQLite writes holidays into a holidays table in the SQLite database
+// at path, creating both if needed. There is one row per holiday per state
+// so queries can filter by state directly. Rows are upserted on the
+// holiday's ID and state, so re-running against the same file is
+// idempotent even if a holiday's spelling changed in between.
+func SaveSQLite(path string, holidays []Holiday) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := migrateSQLite(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(sqliteSchema); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO holidays (id, date, day, name, state) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id, state) DO UPDATE SET day = excluded.day, name = excluded.name`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, h := range holidays {
+		id := h.ID()
+		for _, st := range h.States {
+			if _, err := stmt.Exec(id, h.Date, h.Day, h.Name, st); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// migrateSQLite rebuilds a holidays table from before rows had an id
+// column, copying the old rows back in keyed by ID. Rows that only differed
+// in spelling collapse into one.
+func migrateSQLite(tx *sql.Tx) error {
+	var tables, idColumns int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'holidays'`).Scan(&tables); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('holidays') WHERE name = 'id'`).Scan(&idColumns); err != nil {
+		return err
+	}
+	if tables == 0 || idColumns > 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(`SELECT date, day, name, state FROM holidays`)
+	if err != nil {
+		return err
+	}
+	var old []Holiday
+	for rows.Next() {
+		var h Holiday
+		var st string
+		if err := rows.Scan(&h.Date, &h.Day, &h.Name, &st); err != nil {
+			rows.Close()
+			return err
+		}
+		h.States = []string{st}
+		old = append(old, h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DROP TABLE holidays`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(sqliteSchema); err != nil {
+		return err
+	}
+	for _, h := range old {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO holidays (id, date, day, name, state) VALUES (?, ?, ?, ?, ?)`,
+			h.ID(), h.Date, h.Day, h.Name, h.States[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package scraper
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveSQLiteUpsertsByID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holidays.sqlite")
+
+	// a database written before rows had an id column
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`
+		CREATE TABLE holidays (date TEXT NOT NULL, day TEXT NOT NULL, name TEXT NOT NULL, state TEXT NOT NULL, PRIMARY KEY (date, name, state));
+		INSERT INTO holidays VALUES ('2025-12-31', 'Wednesday', 'New Years Eve', 'johor');
+		INSERT INTO holidays VALUES ('2025-12-31', 'Wednesday', 'New Year''s Eve', 'johor')`); err != nil {
+		t.Fatal(err)
+	}
+
+	holidays := []Holiday{
+		{Date: "2025-12-31", Day: "Wednesday", Name: "New Year's Eve", States: []string{"johor", "selangor"}},
+		{Date: "2025-01-01", Day: "Wednesday", Name: "New Year's Day", States: []string{"johor"}},
+	}
+	for range 2 {
+		if err := SaveSQLite(path, holidays); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM holidays`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d rows, want 3 (one per holiday per state)", n)
+	}
+	var id string
+	if err := db.QueryRow(`SELECT id FROM holidays WHERE state = 'johor' AND date = '2025-12-31'`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if id != holidays[0].ID() {
+		t.Errorf("id = %s, want %s", id, holidays[0].ID())
+	}
+}
@@ -0,0 +1,83 @@
+package scraper
+
+import (
+	"slices"
+	"strings"
+)
+
+// National is the pseudo-state for the site's nationwide holiday page.
+// Holidays fetched from it are tagged States: ["national"] as-is rather than
+// expanded to every state, since the state pages already list the federal
+// holidays each state observes.
+const National = "national"
+
+// states is every state (and federal territory) page on the site
+var states = []string{
+	"johor", "kedah", "kelantan", "kuala-lumpur",
+	"labuan", "melaka", "negeri-sembilan", "pahang",
+	"penang", "perak", "perlis", "putrajaya",
+	"sabah", "sarawak", "selangor", "terengganu",
+}
+
+// AllStates returns the canonical slugs of all 16 states and federal
+// territories, the set scraped by default. National is not included since
+// its holidays already appear on every state page; see ValidStates.
+func AllStates() []string {
+	return slices.Clone(states)
+}
+
+// ValidStates returns every slug FetchState accepts: AllStates plus
+// National, which is only fetched when asked for explicitly.
+func ValidStates() []string {
+	return append(AllStates(), National)
+}
+
+// stateCodes maps the standard three-letter state codes to slugs
+var stateCodes = map[string]string{
+	"JHR": "johor",
+	"KDH": "kedah",
+	"KTN": "kelantan",
+	"KUL": "kuala-lumpur",
+	"LBN": "labuan",
+	"MLK": "melaka",
+	"NSN": "negeri-sembilan",
+	"PHG": "pahang",
+	"PNG": "penang",
+	"PRK": "perak",
+	"PLS": "perlis",
+	"PJY": "putrajaya",
+	"SBH": "sabah",
+	"SWK": "sarawak",
+	"SGR": "selangor",
+	"TRG": "terengganu",
+}
+
+// StateCodes returns the accepted state codes, sorted
+func StateCodes() []string {
+	codes := make([]string, 0, len(stateCodes))
+	for c := range stateCodes {
+		codes = append(codes, c)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// codeToSlug resolves a state code such as "SGR" (any case) to its slug
+func codeToSlug(code string) (string, bool) {
+	slug, ok := stateCodes[strings.ToUpper(code)]
+	return slug, ok
+}
+
+// ResolveState turns a slug or a state code into the canonical slug,
+// reporting false if s is neither. National is accepted as a slug.
+func ResolveState(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if slug, ok := codeToSlug(s); ok {
+		return slug, true
+	}
+	slug := strings.ToLower(s)
+	if slices.Contains(ValidStates(), slug) {
+		return slug, true
+	}
+	return "", false
+}
@@ -0,0 +1,24 @@
+package scraper
+
+import "testing"
+
+func TestResolveState(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"selangor", "selangor", true},
+		{"SGR", "selangor", true},
+		{"nsn", "negeri-sembilan", true},
+		{" KUL ", "kuala-lumpur", true},
+		{"national", "national", true},
+		{"XYZ", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ResolveState(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ResolveState(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package scraper
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CountByState tallies how many of holidays each state observes in year.
+// States with none are absent from the map.
+func CountByState(holidays []Holiday, year int) map[string]int {
+	prefix := strconv.Itoa(year) + "-"
+	counts := make(map[string]int)
+	for _, h := range holidays {
+		if !strings.HasPrefix(h.Date, prefix) {
+			continue
+		}
+		for _, st := range h.States {
+			counts[st]++
+		}
+	}
+	return counts
+}
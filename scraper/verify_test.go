@@ -0,0 +1,18 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCountByState(t *testing.T) {
+	holidays := []Holiday{
+		{Date: "2025-01-01", Name: "New Year's Day", States: []string{"kuala-lumpur", "selangor"}},
+		{Date: "2025-02-01", Name: "Federal Territory Day", States: []string{"kuala-lumpur"}},
+		{Date: "2026-01-01", Name: "New Year's Day", States: []string{"selangor"}},
+	}
+	want := map[string]int{"kuala-lumpur": 2, "selangor": 1}
+	if got := CountByState(holidays, 2025); !reflect.DeepEqual(got, want) {
+		t.Errorf("CountByState(2025) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package scraper
+
+import (
+	"io"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Writer encodes holidays in one output format
+type Writer interface {
+	Write(w io.Writer, holidays []Holiday) error
+}
+
+// WriterFunc adapts a function like WriteJSON to Writer
+type WriterFunc func(w io.Writer, holidays []Holiday) error
+
+// Write calls f(w, holidays)
+func (f WriterFunc) Write(w io.Writer, holidays []Holiday) error {
+	return f(w, holidays)
+}
+
+var (
+	writersMu sync.RWMutex
+	// writers maps each format name to its Writer. SQLite isn't here: a
+	// database is upserted in place rather than streamed, see SaveSQLite.
+	writers = map[string]Writer{
+		"json":  WriterFunc(WriteJSON),
+		"jsonl": WriterFunc(WriteJSONL),
+		"csv":   WriterFunc(WriteCSV),
+		"tsv":   WriterFunc(WriteTSV),
+		"ics":   WriterFunc(WriteICS),
+		"yaml":  WriterFunc(WriteYAML),
+		"md":    WriterFunc(WriteMarkdown),
+		"xml":   WriterFunc(WriteXML),
+		"xlsx":  WriterFunc(WriteXLSX),
+	}
+)
+
+// RegisterWriter makes w the Writer for format, replacing any built-in one,
+// so library users and forks can add formats without touching the CLI's
+// format handling. Register before writing; it's safe to call concurrently.
+func RegisterWriter(format string, w Writer) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	writers[format] = w
+}
+
+// LookupWriter returns the Writer registered for format
+func LookupWriter(format string) (Writer, bool) {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
+	w, ok := writers[format]
+	return w, ok
+}
+
+// Formats lists the registered format names, sorted
+func Formats() []string {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
+	return slices.Sorted(maps.Keys(writers))
+}
@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestBuiltinWriters(t *testing.T) {
+	holidays := []Holiday{{Date: "2025-08-31", Day: "Sunday", Name: "National Day", States: []string{"selangor"}}}
+	for _, format := range Formats() {
+		w, ok := LookupWriter(format)
+		if !ok {
+			t.Fatalf("%s: listed but not registered", format)
+		}
+		var buf bytes.Buffer
+		if err := w.Write(&buf, holidays); err != nil {
+			t.Errorf("%s: %v", format, err)
+		} else if buf.Len() == 0 {
+			t.Errorf("%s: wrote nothing", format)
+		}
+	}
+}
+
+func TestRegisterWriter(t *testing.T) {
+	RegisterWriter("names", WriterFunc(func(w io.Writer, holidays []Holiday) error {
+		for _, h := range holidays {
+			if _, err := io.WriteString(w, h.Name+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	t.Cleanup(func() {
+		writersMu.Lock()
+		delete(writers, "names")
+		writersMu.Unlock()
+	})
+
+	if !slices.Contains(Formats(), "names") {
+		t.Errorf("Formats() = %v, missing names", Formats())
+	}
+	w, ok := LookupWriter("names")
+	if !ok {
+		t.Fatal("names writer not found")
+	}
+	var buf bytes.Buffer
+	if err := w.Write(&buf, []Holiday{{Name: "Labour Day"}, {Name: "Wesak Day"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "Labour Day\nWesak Day\n" {
+		t.Errorf("wrote %q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxSheet is the name of the single sheet SaveXLSX writes
+const xlsxSheet = "Holidays"
+
+// Save to Excel
+func SaveXLSX(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteXLSX)
+}
+
+// WriteXLSX writes holidays to w as an Excel workbook with one row per
+// holiday: a bold, frozen Date/Day/Name/States header, states joined by
+// ", ", and columns sized to their widest cell.
+func WriteXLSX(w io.Writer, holidays []Holiday) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", xlsxSheet); err != nil {
+		return err
+	}
+
+	rows := [][]string{{"Date", "Day", "Name", "States"}}
+	for _, h := range holidays {
+		rows = append(rows, []string{h.Date, h.Day, h.Name, strings.Join(h.States, ", ")})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		values := make([]any, len(row))
+		for j, v := range row {
+			values[j] = v
+			widths[j] = max(widths[j], utf8.RuneCountInString(v))
+		}
+		if err := f.SetSheetRow(xlsxSheet, cell, &values); err != nil {
+			return err
+		}
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	if err := f.SetRowStyle(xlsxSheet, 1, 1, bold); err != nil {
+		return err
+	}
+	for j, width := range widths {
+		col, err := excelize.ColumnNumberToName(j + 1)
+		if err != nil {
+			return err
+		}
+		// a little padding so text doesn't touch the cell border
+		if err := f.SetColWidth(xlsxSheet, col, col, float64(width)+2); err != nil {
+			return err
+		}
+	}
+	if err := f.SetPanes(xlsxSheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return fmt.Errorf("freezing header: %w", err)
+	}
+
+	_, err = f.WriteTo(w)
+	return err
+}
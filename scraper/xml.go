@@ -0,0 +1,83 @@
+package scraper
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+)
+
+// xmlHolidays is the document root WriteXML produces
+type xmlHolidays struct {
+	XMLName  xml.Name     `xml:"holidays"`
+	Holidays []xmlHoliday `xml:"holiday"`
+}
+
+// xmlHoliday mirrors Holiday; encoding/xml can't marshal ObservedDays'
+// map, so it becomes a list of <observed state="...">
+type xmlHoliday struct {
+	Date         string        `xml:"date"`
+	Day          string        `xml:"day"`
+	Name         string        `xml:"name"`
+	States       []string      `xml:"states>state"`
+	InLieu       bool          `xml:"inLieu,omitempty"`
+	OriginalDate string        `xml:"originalDate,omitempty"`
+	ObservedDays []xmlObserved `xml:"observedDays>observed,omitempty"`
+	Type         string        `xml:"type,omitempty"`
+	OnWeekend    bool          `xml:"onWeekend,omitempty"`
+	NameEn       string        `xml:"nameEn,omitempty"`
+	NameMs       string        `xml:"nameMs,omitempty"`
+	Note         string        `xml:"note,omitempty"`
+	ActualDate   string        `xml:"actualDate,omitempty"`
+	ObservedDate string        `xml:"observedDate,omitempty"`
+	HijriDate    string        `xml:"hijriDate,omitempty"`
+}
+
+type xmlObserved struct {
+	State string `xml:"state,attr"`
+	Day   string `xml:",chardata"`
+}
+
+// Save to XML
+func SaveXML(path string, holidays []Holiday) error {
+	return saveFile(path, holidays, WriteXML)
+}
+
+// WriteXML writes holidays to w as an indented
+// <holidays><holiday>...</holiday></holidays> document
+func WriteXML(w io.Writer, holidays []Holiday) error {
+	doc := xmlHolidays{Holidays: make([]xmlHoliday, len(holidays))}
+	for i, h := range holidays {
+		x := xmlHoliday{
+			Date:         h.Date,
+			Day:          h.Day,
+			Name:         h.Name,
+			States:       h.States,
+			InLieu:       h.InLieu,
+			OriginalDate: h.OriginalDate,
+			Type:         h.Type,
+			OnWeekend:    h.OnWeekend,
+			NameEn:       h.NameEn,
+			NameMs:       h.NameMs,
+			Note:         h.Note,
+			ActualDate:   h.ActualDate,
+			ObservedDate: h.ObservedDate,
+			HijriDate:    h.HijriDate,
+		}
+		for st, day := range h.ObservedDays {
+			x.ObservedDays = append(x.ObservedDays, xmlObserved{State: st, Day: day})
+		}
+		sort.Slice(x.ObservedDays, func(a, b int) bool { return x.ObservedDays[a].State < x.ObservedDays[b].State })
+		doc.Holidays[i] = x
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
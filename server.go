@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/farizkhoo/cuti-cli/scraper"
+)
+
+// holidayServer answers /holidays from an in-memory copy of each year's
+// consolidated holidays, scraping a year the first time it's asked for.
+// Only the years the server was started with can be asked for.
+// Like a scrape's output, observances are left out unless
+// includeObservances is set. When some states fail, the rest are served
+// marked as partial, or not at all with failOnPartial.
+type holidayServer struct {
+	fetch              func(ctx context.Context, year int, states []string) ([]scraper.Holiday, error)
+	states             []string
+	years              []int
+	maxAge             time.Duration
+	includeObservances bool
+	failOnPartial      bool
+
+	// mu serializes scrapes; one year's fetch already fans out across tabs
+	mu      sync.Mutex
+	byYear  map[int][]scraper.Holiday
+	fetched map[int]time.Time
+}
+
+func newHolidayServer(s *scraper.Scraper, states []string, years []int, maxAge time.Duration, includeObservances, failOnPartial bool) *holidayServer {
+	return &holidayServer{
+		fetch:              s.FetchAll,
+		states:             states,
+		years:              years,
+		maxAge:             maxAge,
+		includeObservances: includeObservances,
+		failOnPartial:      failOnPartial,
+		byYear:             map[int][]scraper.Holiday{},
+		fetched:            map[int]time.Time{},
+	}
+}
+
+func (hs *holidayServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /holidays", hs.handleHolidays)
+	return mux
+}
+
+// handleHolidays serves GET /holidays?year=2025&state=selangor. Both
+// parameters are optional: year defaults to the first of the server's years,
+// and state, a slug or code like SGR, narrows the result to holidays that
+// state observes.
+func (hs *holidayServer) handleHolidays(w http.ResponseWriter, r *http.Request) {
+	year := hs.years[0]
+	if v := r.URL.Query().Get("year"); v != "" {
+		y, err := strconv.Atoi(v)
+		if err != nil || !slices.Contains(hs.years, y) {
+			http.Error(w, fmt.Sprintf("bad year %q (served: %s)", v, yearsList(hs.years)), http.StatusBadRequest)
+			return
+		}
+		year = y
+	}
+
+	var state string
+	if v := r.URL.Query().Get("state"); v != "" {
+		st, ok := scraper.ResolveState(v)
+		if !ok || !slices.Contains(hs.states, st) {
+			http.Error(w, fmt.Sprintf("unknown state %q (valid: %s)", v, strings.Join(hs.states, ", ")), http.StatusBadRequest)
+			return
+		}
+		state = st
+	}
+
+	holidays, err := hs.holidays(r.Context(), year)
+	if err != nil && (len(holidays) == 0 || hs.failOnPartial) {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err != nil {
+		// still useful, but the client has to be able to tell
+		failed := failedStates(err)
+		slog.Warn(fmt.Sprintf("⚠️  Serving partial holidays for %d; failed: %s", year, strings.Join(failed, ", ")),
+			"year", year, "failed", failed, "error", err)
+		w.Header().Set("X-Partial", "true")
+		w.Header().Set("Warning", `199 cuti-cli `+strconv.Quote("partial result; failed: "+strings.Join(failed, ", ")))
+	}
+	if state != "" {
+		holidays = scraper.FilterByState(holidays, state)
+	}
+	if holidays == nil {
+		holidays = []scraper.Holiday{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(holidays); err != nil {
+		slog.Error(fmt.Sprintf("⛔ Writing response: %v", err), "error", err)
+	}
+}
+
+// holidays returns year's consolidated holidays, scraping them if they
+// aren't cached or are older than maxAge. A scrape is abandoned if ctx,
+// the request's context, ends first. If some states failed, what the
+// others returned comes with the error and isn't cached.
+func (hs *holidayServer) holidays(ctx context.Context, year int) ([]scraper.Holiday, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if at, ok := hs.fetched[year]; ok && (hs.maxAge <= 0 || time.Since(at) < hs.maxAge) {
+		return hs.byYear[year], nil
+	}
+
+	holidays, err := hs.fetch(ctx, year, hs.states)
+	if !hs.includeObservances {
+		holidays = scraper.FilterGazetted(holidays)
+	}
+	if len(holidays) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no holidays found for %d", year)
+		}
+		return nil, err
+	}
+	// serve partial results, but try again next time
+	if err == nil {
+		hs.fetched[year] = time.Now()
+		hs.byYear[year] = holidays
+	}
+	return holidays, err
+}
+
+// yearsList joins years with commas, for messages
+func yearsList(years []int) string {
+	parts := make([]string, len(years))
+	for i, y := range years {
+		parts[i] = strconv.Itoa(y)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// serve runs the HTTP API on addr until the process is stopped
+func serve(addr string, hs *holidayServer) error {
+	slog.Info(fmt.Sprintf("🚀 Serving holidays on http://%s (GET /holidays, /healthz)", addr), "addr", addr)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           hs.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/farizkhoo/cuti-cli/scraper"
+)
+
+// fixtureServer is a holidayServer whose fetches parse the scraper's saved
+// pages instead of loading them in Chrome
+func fixtureServer(t *testing.T, includeObservances bool) *holidayServer {
+	t.Helper()
+	page, err := os.ReadFile("scraper/testdata/observances-2025.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := newHolidayServer(nil, []string{"penang"}, []int{2025}, 0, includeObservances, false)
+	hs.fetch = func(_ context.Context, year int, states []string) ([]scraper.Holiday, error) {
+		holidays, err := scraper.ParseHolidays(string(page), states[0], year)
+		return scraper.Consolidate(holidays), err
+	}
+	return hs
+}
+
+func getHolidays(t *testing.T, hs *holidayServer) []string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	hs.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/holidays?year=2025", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /holidays = %d: %s", rec.Code, rec.Body)
+	}
+	var holidays []scraper.Holiday
+	if err := json.Unmarshal(rec.Body.Bytes(), &holidays); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, h := range holidays {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+func TestHolidaysDropsObservances(t *testing.T) {
+	if got, want := getHolidays(t, fixtureServer(t, false)), []string{"New Year's Day", "Labour Day", "George Town World Heritage City Day"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+	if got := getHolidays(t, fixtureServer(t, true)); len(got) != 6 {
+		t.Errorf("with observances served %v, want all 6 rows", got)
+	}
+}
+
+func TestHolidaysPartial(t *testing.T) {
+	hs := fixtureServer(t, false)
+	parse := hs.fetch
+	hs.states = []string{"penang", "kedah"}
+	hs.fetch = func(ctx context.Context, year int, states []string) ([]scraper.Holiday, error) {
+		holidays, _ := parse(ctx, year, states[:1])
+		return holidays, errors.Join(&scraper.FetchError{State: "kedah", Year: year, Err: scraper.ErrTimeout})
+	}
+
+	rec := httptest.NewRecorder()
+	hs.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/holidays", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Partial") != "true" {
+		t.Fatalf("GET /holidays = %d, X-Partial %q; want 200 marked partial", rec.Code, rec.Header().Get("X-Partial"))
+	}
+	if w := rec.Header().Get("Warning"); !strings.Contains(w, "kedah (2025)") {
+		t.Errorf("Warning = %q, want the failed state", w)
+	}
+	var holidays []scraper.Holiday
+	if err := json.Unmarshal(rec.Body.Bytes(), &holidays); err != nil || len(holidays) != 3 {
+		t.Errorf("served %d holidays (%v), want penang's 3", len(holidays), err)
+	}
+
+	hs.failOnPartial = true
+	rec = httptest.NewRecorder()
+	hs.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/holidays", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("with failOnPartial GET /holidays = %d, want 502", rec.Code)
+	}
+}
+
+func TestHolidaysQuery(t *testing.T) {
+	hs := fixtureServer(t, false)
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusOK},
+		{"?year=2025&state=penang", http.StatusOK},
+		{"?state=PNG", http.StatusOK},
+		{"?state=png", http.StatusOK},
+		{"?state=SGR", http.StatusBadRequest},
+		{"?state=atlantis", http.StatusBadRequest},
+		{"?year=2026", http.StatusBadRequest},
+		{"?year=-1", http.StatusBadRequest},
+		{"?year=twenty", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		hs.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/holidays"+tt.query, nil))
+		if rec.Code != tt.code {
+			t.Errorf("GET /holidays%s = %d, want %d: %s", tt.query, rec.Code, tt.code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/farizkhoo/cuti-cli/scraper"
+)
+
+var (
+	tuiBorder = lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240"))
+	tuiHelp   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	tuiLabel  = lipgloss.NewStyle().Bold(true).Width(14)
+)
+
+// browseModel is the -tui holiday browser: a table of holidays narrowed by a
+// filter box, with a detail view for the selected row.
+type browseModel struct {
+	all      []scraper.Holiday
+	shown    []scraper.Holiday
+	table    table.Model
+	filter   textinput.Model
+	detail   *scraper.Holiday
+	filtered string
+}
+
+// browse opens the holiday browser and blocks until the user quits
+func browse(holidays []scraper.Holiday) error {
+	_, err := tea.NewProgram(newBrowseModel(holidays), tea.WithAltScreen()).Run()
+	return err
+}
+
+func newBrowseModel(holidays []scraper.Holiday) browseModel {
+	filter := textinput.New()
+	filter.Placeholder = "name or state"
+	filter.Prompt = "/ "
+
+	t := table.New(table.WithFocused(true), table.WithHeight(20))
+	styles := table.DefaultStyles()
+	styles.Header = styles.Header.BorderStyle(lipgloss.NormalBorder()).BorderBottom(true).Bold(true)
+	t.SetStyles(styles)
+
+	m := browseModel{all: holidays, table: t, filter: filter}
+	m.resize(100)
+	m.apply("")
+	return m
+}
+
+func (m browseModel) Init() tea.Cmd { return nil }
+
+func (m browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width)
+		// room for the border, filter box and help line
+		m.table.SetHeight(max(msg.Height-6, 3))
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if m.detail != nil {
+			switch msg.String() {
+			case "esc", "enter", "backspace", "q":
+				m.detail = nil
+			}
+			return m, nil
+		}
+		if m.filter.Focused() {
+			switch msg.String() {
+			case "enter", "esc":
+				m.filter.Blur()
+				m.table.Focus()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.filter, cmd = m.filter.Update(msg)
+			if v := m.filter.Value(); v != m.filtered {
+				m.apply(v)
+			}
+			return m, cmd
+		}
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "/":
+			m.table.Blur()
+			return m, m.filter.Focus()
+		case "esc":
+			m.filter.SetValue("")
+			m.apply("")
+			return m, nil
+		case "enter":
+			if i := m.table.Cursor(); i >= 0 && i < len(m.shown) {
+				h := m.shown[i]
+				m.detail = &h
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m browseModel) View() string {
+	if m.detail != nil {
+		return m.detailView(*m.detail)
+	}
+	help := fmt.Sprintf("%d/%d holidays • ↑/↓ move • / filter • enter details • esc clear • q quit", len(m.shown), len(m.all))
+	return tuiBorder.Render(m.table.View()) + "\n" + m.filter.View() + "\n" + tuiHelp.Render(help)
+}
+
+func (m browseModel) detailView(h scraper.Holiday) string {
+	var b strings.Builder
+	row := func(label, value string) {
+		if value != "" {
+			b.WriteString(tuiLabel.Render(label) + value + "\n")
+		}
+	}
+	row("Name", h.Name)
+	row("Date", h.Date)
+	row("Day", h.Day)
+	row("States", strings.Join(h.States, ", "))
+	row("Type", h.Type)
+	row("Hijri", h.HijriDate)
+	if h.InLieu {
+		row("In lieu of", cmp.Or(h.OriginalDate, "yes"))
+	}
+	if h.OnWeekend {
+		row("On weekend", "yes")
+	}
+	row("Note", h.Note)
+	if h.ActualDate != "" {
+		row("Most states", h.ActualDate)
+	}
+	for _, st := range h.States {
+		if d, ok := h.ObservedDays[st]; ok {
+			row("Day in "+st, d)
+		}
+	}
+	return tuiBorder.Padding(0, 1).Render(strings.TrimRight(b.String(), "\n")) + "\n" +
+		tuiHelp.Render("esc back • ctrl+c quit")
+}
+
+// apply narrows the table to holidays whose name or a state contains query,
+// ignoring case
+func (m *browseModel) apply(query string) {
+	m.filtered = query
+	query = strings.ToLower(strings.TrimSpace(query))
+	m.shown = nil
+	for _, h := range m.all {
+		if query == "" || matchesQuery(h, query) {
+			m.shown = append(m.shown, h)
+		}
+	}
+	rows := make([]table.Row, len(m.shown))
+	for i, h := range m.shown {
+		rows[i] = table.Row{h.Date, h.Day, h.Name, strings.Join(h.States, ", ")}
+	}
+	m.table.SetRows(rows)
+	m.table.SetCursor(0)
+}
+
+func matchesQuery(h scraper.Holiday, query string) bool {
+	if strings.Contains(strings.ToLower(h.Name), query) {
+		return true
+	}
+	for _, st := range h.States {
+		if strings.Contains(st, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// resize splits the terminal width between the columns, giving Name and
+// States whatever the fixed-width Date and Day columns leave over
+func (m *browseModel) resize(width int) {
+	rest := max(width-10-10-10, 20)
+	m.table.SetColumns([]table.Column{
+		{Title: "Date", Width: 10},
+		{Title: "Day", Width: 10},
+		{Title: "Name", Width: rest / 2},
+		{Title: "States", Width: rest - rest/2},
+	})
+}